@@ -0,0 +1,174 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"fmt"
+)
+
+// These are the minimum and maximum number of items accepted by Telegram in an album.
+const (
+	MinAlbumSize = 2
+	MaxAlbumSize = 10
+)
+
+// AlbumOptions contains the optional parameters used by the SendAlbum method.
+type AlbumOptions struct {
+	Caption         string
+	ParseMode       ParseMode
+	CaptionEntities []*MessageEntity
+	MediaGroupOptions
+}
+
+// ValidateAlbum checks that media can be sent with SendAlbum: it must have at least MinAlbumSize items,
+// and either only photos and videos, only audio files or only documents, as values or pointers.
+func ValidateAlbum(media []GroupableInputMedia) error {
+	if len(media) < MinAlbumSize {
+		return fmt.Errorf("an album needs at least %d items, got %d", MinAlbumSize, len(media))
+	}
+
+	first := albumKind(media[0])
+	for i, m := range media {
+		kind := albumKind(m)
+		if kind == "" {
+			return fmt.Errorf("unsupported album item %d of type %T", i, m)
+		}
+		if kind != first {
+			return fmt.Errorf("album item %d: can't mix %s with %s", i, kind, first)
+		}
+	}
+	return nil
+}
+
+// SplitAlbum splits media into consecutive chunks of at most MaxAlbumSize items,
+// each one sent as a separate album by SendAlbum.
+// The items are spread evenly so that no chunk is left with a single item.
+func SplitAlbum(media []GroupableInputMedia) [][]GroupableInputMedia {
+	if len(media) == 0 {
+		return nil
+	}
+
+	var (
+		n      = (len(media) + MaxAlbumSize - 1) / MaxAlbumSize
+		size   = len(media) / n
+		extra  = len(media) % n
+		chunks = make([][]GroupableInputMedia, 0, n)
+	)
+
+	for i := 0; i < n; i++ {
+		l := size
+		if i < extra {
+			l++
+		}
+		chunks = append(chunks, media[:l:l])
+		media = media[l:]
+	}
+	return chunks
+}
+
+// SendAlbum is like SendMediaGroup but accepts any number of items, sending them as several
+// consecutive albums of at most MaxAlbumSize items, after checking them with ValidateAlbum.
+// The caption in opts, if any, replaces the one of the first item of each album,
+// while the reply parameters apply only to the first album.
+// All the messages sent are returned, even when an error interrupts the sequence.
+func (a API) SendAlbum(chatID int64, media []GroupableInputMedia, opts *AlbumOptions) (res []*Message, err error) {
+	if err := ValidateAlbum(media); err != nil {
+		return nil, err
+	}
+
+	for i, chunk := range SplitAlbum(media) {
+		var o AlbumOptions
+
+		if opts != nil {
+			o = *opts
+		}
+		if i > 0 {
+			o.ReplyParameters = ReplyParameters{}
+		}
+
+		if o.Caption != "" {
+			chunk = append([]GroupableInputMedia{withCaption(chunk[0], o)}, chunk[1:]...)
+		}
+
+		r, err := a.SendMediaGroup(chatID, chunk, &o.MediaGroupOptions)
+		res = append(res, r.Result...)
+		if err != nil {
+			return res, err
+		}
+	}
+	return
+}
+
+// albumItem returns the value pointed by m if it's a pointer to one of the groupable media,
+// so that they're handled like the values. A nil pointer is returned as is.
+func albumItem(m GroupableInputMedia) GroupableInputMedia {
+	switch i := m.(type) {
+	case *InputMediaPhoto:
+		if i != nil {
+			return *i
+		}
+	case *InputMediaVideo:
+		if i != nil {
+			return *i
+		}
+	case *InputMediaAudio:
+		if i != nil {
+			return *i
+		}
+	case *InputMediaDocument:
+		if i != nil {
+			return *i
+		}
+	}
+	return m
+}
+
+// albumKind returns the kind of the items which m can be grouped with.
+func albumKind(m GroupableInputMedia) string {
+	switch albumItem(m).(type) {
+	case InputMediaPhoto, InputMediaVideo:
+		return "photos and videos"
+	case InputMediaAudio:
+		return "audio files"
+	case InputMediaDocument:
+		return "documents"
+	default:
+		return ""
+	}
+}
+
+// withCaption returns a copy of m with the caption in opts.
+func withCaption(m GroupableInputMedia, opts AlbumOptions) GroupableInputMedia {
+	switch i := albumItem(m).(type) {
+	case InputMediaPhoto:
+		i.Caption, i.ParseMode, i.CaptionEntities = opts.Caption, opts.ParseMode, opts.CaptionEntities
+		return i
+	case InputMediaVideo:
+		i.Caption, i.ParseMode, i.CaptionEntities = opts.Caption, opts.ParseMode, opts.CaptionEntities
+		return i
+	case InputMediaAudio:
+		i.Caption, i.ParseMode, i.CaptionEntities = opts.Caption, opts.ParseMode, opts.CaptionEntities
+		return i
+	case InputMediaDocument:
+		i.Caption, i.ParseMode, i.CaptionEntities = opts.Caption, opts.ParseMode, opts.CaptionEntities
+		return i
+	default:
+		return m
+	}
+}
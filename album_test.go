@@ -0,0 +1,136 @@
+package echosphere
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func photos(n int) []GroupableInputMedia {
+	media := make([]GroupableInputMedia, n)
+	for i := range media {
+		media[i] = InputMediaPhoto{Type: MediaTypePhoto, Media: NewInputFileID(fmt.Sprint("photo", i))}
+	}
+	return media
+}
+
+func TestValidateAlbum(t *testing.T) {
+	valid := [][]GroupableInputMedia{
+		photos(2),
+		photos(25),
+		{InputMediaPhoto{Type: MediaTypePhoto}, InputMediaVideo{Type: MediaTypeVideo}},
+		{InputMediaDocument{Type: MediaTypeDocument}, InputMediaDocument{Type: MediaTypeDocument}},
+		{&InputMediaPhoto{Type: MediaTypePhoto}, InputMediaVideo{Type: MediaTypeVideo}},
+	}
+	for _, media := range valid {
+		if err := ValidateAlbum(media); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	invalid := [][]GroupableInputMedia{
+		photos(1),
+		{InputMediaPhoto{Type: MediaTypePhoto}, InputMediaAudio{Type: MediaTypeAudio}},
+		{InputMediaDocument{Type: MediaTypeDocument}, InputMediaVideo{Type: MediaTypeVideo}},
+		{InputMediaPhoto{Type: MediaTypePhoto}, nil},
+		{&InputMediaPhoto{Type: MediaTypePhoto}, &InputMediaAudio{Type: MediaTypeAudio}},
+		{&InputMediaPhoto{Type: MediaTypePhoto}, (*InputMediaVideo)(nil)},
+	}
+	for _, media := range invalid {
+		if err := ValidateAlbum(media); err == nil {
+			t.Fatalf("expected an error for %+v", media)
+		}
+	}
+}
+
+func TestAlbumCaptionPointer(t *testing.T) {
+	photo := &InputMediaPhoto{Type: MediaTypePhoto, Caption: "old"}
+
+	m, ok := withCaption(photo, AlbumOptions{Caption: "new"}).(InputMediaPhoto)
+	if !ok || m.Caption != "new" {
+		t.Fatalf("unexpected item %+v", m)
+	}
+	if photo.Caption != "old" {
+		t.Fatalf("expected the item of the caller to be left unchanged, got %q", photo.Caption)
+	}
+}
+
+func TestSplitAlbum(t *testing.T) {
+	for n, sizes := range map[int][]int{
+		2:  {2},
+		10: {10},
+		11: {6, 5},
+		25: {9, 8, 8},
+	} {
+		chunks := SplitAlbum(photos(n))
+		if len(chunks) != len(sizes) {
+			t.Fatalf("expected %d chunks for %d items, got %d", len(sizes), n, len(chunks))
+		}
+		for i, c := range chunks {
+			if len(c) != sizes[i] {
+				t.Fatalf("unexpected chunk sizes for %d items: %d instead of %d", n, len(c), sizes[i])
+			}
+		}
+	}
+}
+
+func TestSendAlbum(t *testing.T) {
+	disableChatLimit(t)
+
+	var (
+		mu     sync.Mutex
+		albums [][]map[string]any
+		nextID int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var media []map[string]any
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !strings.HasSuffix(r.URL.Path, "/sendMediaGroup") || json.Unmarshal([]byte(r.FormValue("media")), &media) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request"}`))
+			return
+		}
+		if r.FormValue("reply_parameters") != "" && len(albums) > 0 {
+			t.Errorf("unexpected reply parameters in album %d", len(albums))
+		}
+		albums = append(albums, media)
+
+		msgs := make([]*Message, len(media))
+		for i := range msgs {
+			nextID++
+			msgs[i] = &Message{ID: nextID}
+		}
+		json.NewEncoder(w).Encode(APIResponseMessageArray{APIResponseBase: APIResponseBase{Ok: true}, Result: msgs})
+	}))
+	defer srv.Close()
+
+	api := NewLocalAPI(srv.URL, "123:token")
+	msgs, err := api.SendAlbum(1101, photos(12), &AlbumOptions{
+		Caption:           "<b>holidays</b>",
+		ParseMode:         HTML,
+		MediaGroupOptions: MediaGroupOptions{ReplyParameters: ReplyParameters{MessageID: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(msgs) != 12 || msgs[11].ID != 12 || len(albums) != 2 || len(albums[0]) != 6 || len(albums[1]) != 6 {
+		t.Fatalf("unexpected messages %d in albums %+v", len(msgs), albums)
+	}
+	for _, album := range albums {
+		if album[0]["caption"] != "<b>holidays</b>" || album[0]["parse_mode"] != "HTML" || album[1]["caption"] != nil {
+			t.Fatalf("unexpected captions in album %+v", album)
+		}
+	}
+
+	if _, err := api.SendAlbum(1101, photos(1), nil); err == nil {
+		t.Fatal("expected an error for a single item")
+	}
+}
@@ -19,37 +19,57 @@
 package echosphere
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"strings"
 )
 
 // API is the object that contains all the functions that wrap those of the Telegram Bot API.
 type API struct {
-	token  string
-	base   string
-	client *client
+	token    string
+	base     string
+	fileBase string
+	local    bool
+	cache    FileIDStore
+	client   *client
 }
 
 // NewAPI returns a new API object.
 func NewAPI(token string) API {
 	return API{
-		token:  token,
-		base:   fmt.Sprintf("https://api.telegram.org/bot%s/", token),
-		client: lclient,
+		token:    token,
+		base:     fmt.Sprintf("https://api.telegram.org/bot%s/", token),
+		fileBase: fmt.Sprintf("https://api.telegram.org/file/bot%s/", token),
+		client:   lclient,
 	}
 }
 
 // NewLocalAPI is like NewAPI but allows to use a local API server.
+// The url is the address of the server, e.g. http://localhost:8081,
+// the bot<token>/ path prefix is added when missing.
 func NewLocalAPI(url, token string) API {
+	root := localRoot(url, token)
+
 	return API{
-		token:  token,
-		base:   url,
-		client: lclient,
+		token:    token,
+		base:     fmt.Sprintf("%s/bot%s/", root, token),
+		fileBase: fmt.Sprintf("%s/file/bot%s/", root, token),
+		client:   lclient,
 	}
 }
 
+// NewLocalModeAPI is like NewLocalAPI but for a local API server started with the --local flag
+// on the same machine as the bot.
+// The files returned by GetFile with an absolute path are read straight from disk,
+// and the files created with NewInputFilePath are sent by path reference instead of being uploaded.
+func NewLocalModeAPI(url, token string) API {
+	a := NewLocalAPI(url, token)
+	a.local = true
+	return a
+}
+
 // GetUpdates is used to receive incoming updates using long polling.
 func (a API) GetUpdates(opts *UpdateOptions) (res APIResponseUpdate, err error) {
 	return res, a.client.get(a.base, "getUpdates", urlValues(opts), &res)
@@ -58,30 +78,12 @@ func (a API) GetUpdates(opts *UpdateOptions) (res APIResponseUpdate, err error)
 // SetWebhook is used to specify a url and receive incoming updates via an outgoing webhook.
 func (a API) SetWebhook(webhookURL string, dropPendingUpdates bool, opts *WebhookOptions) (res APIResponseBase, err error) {
 	var (
-		vals   = make(url.Values)
-		keyVal = map[string]string{"url": webhookURL}
+		vals = make(url.Values)
+		form = url.Values{"url": {webhookURL}}
 	)
 
-	url, err := url.JoinPath(a.base, "setWebhook")
-	if err != nil {
-		return res, err
-	}
-
 	vals.Set("drop_pending_updates", btoa(dropPendingUpdates))
-	addValues(vals, opts)
-	url = fmt.Sprintf("%s?%s", strings.TrimSuffix(url, "/"), vals.Encode())
-
-	cnt, err := a.client.doPostForm(url, keyVal)
-	if err != nil {
-		return
-	}
-
-	if err = json.Unmarshal(cnt, &res); err != nil {
-		return
-	}
-
-	err = check(res)
-	return
+	return res, a.client.postForm(a.base, "setWebhook", form, addValues(vals, opts), &res)
 }
 
 // DeleteWebhook is used to remove webhook integration if you decide to switch back to GetUpdates.
@@ -193,7 +195,7 @@ func (a API) SendPhoto(file InputFile, chatID int64, opts *PhotoOptions) (res AP
 	var vals = make(url.Values)
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendPhoto", "photo", file, InputFile{}, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendPhoto", "photo", file, InputFile{}, addValues(vals, opts), &res)
 }
 
 // SendAudio is used to send audio files,
@@ -210,7 +212,7 @@ func (a API) SendAudio(file InputFile, chatID int64, opts *AudioOptions) (res AP
 	}
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendAudio", "audio", file, thumbnail, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendAudio", "audio", file, thumbnail, addValues(vals, opts), &res)
 }
 
 // SendDocument is used to send general files.
@@ -225,7 +227,7 @@ func (a API) SendDocument(file InputFile, chatID int64, opts *DocumentOptions) (
 	}
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendDocument", "document", file, thumbnail, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendDocument", "document", file, thumbnail, addValues(vals, opts), &res)
 }
 
 // SendVideo is used to send video files.
@@ -241,7 +243,7 @@ func (a API) SendVideo(file InputFile, chatID int64, opts *VideoOptions) (res AP
 	}
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendVideo", "video", file, thumbnail, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendVideo", "video", file, thumbnail, addValues(vals, opts), &res)
 }
 
 // SendAnimation is used to send animation files (GIF or H.264/MPEG-4 AVC video without sound).
@@ -256,7 +258,7 @@ func (a API) SendAnimation(file InputFile, chatID int64, opts *AnimationOptions)
 	}
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendAnimation", "animation", file, thumbnail, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendAnimation", "animation", file, thumbnail, addValues(vals, opts), &res)
 }
 
 // SendVoice is used to send audio files, if you want Telegram clients to display the file as a playable voice message.
@@ -265,7 +267,7 @@ func (a API) SendVoice(file InputFile, chatID int64, opts *VoiceOptions) (res AP
 	var vals = make(url.Values)
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendVoice", "voice", file, InputFile{}, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendVoice", "voice", file, InputFile{}, addValues(vals, opts), &res)
 }
 
 // SendVideoNote is used to send video messages.
@@ -280,7 +282,7 @@ func (a API) SendVideoNote(file InputFile, chatID int64, opts *VideoNoteOptions)
 	}
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "sendVideoNote", "video_note", file, thumbnail, addValues(vals, opts), &res)
+	return res, a.postMessageFile("sendVideoNote", "video_note", file, thumbnail, addValues(vals, opts), &res)
 }
 
 // SendMediaGroup is used to send a group of photos, videos, documents or audios as an album.
@@ -289,7 +291,12 @@ func (a API) SendMediaGroup(chatID int64, media []GroupableInputMedia, opts *Med
 	var vals = make(url.Values)
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postMedia(a.base, "sendMediaGroup", false, addValues(vals, opts), &res, toInputMedia(media)...)
+	vals = addValues(vals, opts)
+	return res, a.sendMedia(toInputMedia(media), func(media []InputMedia) ([]*Message, error) {
+		res = APIResponseMessageArray{}
+		err := a.client.postMedia(a.base, "sendMediaGroup", false, vals, &res, media...)
+		return res.Result, err
+	})
 }
 
 // SendLocation is used to send point on the map.
@@ -408,12 +415,12 @@ func (a API) GetFile(fileID string) (res APIResponseFile, err error) {
 // DownloadFile returns the bytes of the file corresponding to the given filePath.
 // This function is callable for at least 1 hour since the call to GetFile.
 // When the download expires a new one can be requested by calling GetFile again.
+// Use DownloadFileTo or OpenFile to avoid loading big files in memory.
 func (a API) DownloadFile(filePath string) ([]byte, error) {
-	return a.client.doGet(fmt.Sprintf(
-		"https://api.telegram.org/file/bot%s/%s",
-		a.token,
-		filePath,
-	))
+	var buf bytes.Buffer
+
+	_, err := a.DownloadFileTo(context.Background(), filePath, &buf)
+	return buf.Bytes(), err
 }
 
 // BanChatMember is used to ban a user in a group, a supergroup or a channel.
@@ -582,7 +589,7 @@ func (a API) SetChatPhoto(file InputFile, chatID int64) (res APIResponseBool, er
 	var vals = make(url.Values)
 
 	vals.Set("chat_id", itoa(chatID))
-	return res, a.client.postFile(a.base, "setChatPhoto", "photo", file, InputFile{}, vals, &res)
+	return res, a.client.postFile(a.base, "setChatPhoto", "photo", a.localFile(file), InputFile{}, vals, &res)
 }
 
 // DeleteChatPhoto is used to delete a chat photo.
@@ -945,7 +952,13 @@ func (a API) EditMessageCaption(msg MessageIDOptions, opts *MessageCaptionOption
 // When an inline message is edited, a new file can't be uploaded.
 // Use a previously uploaded file via its file_id or specify a URL.
 func (a API) EditMessageMedia(msg MessageIDOptions, media InputMedia, opts *MessageReplyMarkup) (res APIResponseMessage, err error) {
-	return res, a.client.postMedia(a.base, "editMessageMedia", true, addValues(urlValues(msg), opts), &res, media)
+	var vals = addValues(urlValues(msg), opts)
+
+	return res, a.sendMedia([]InputMedia{media}, func(media []InputMedia) ([]*Message, error) {
+		res = APIResponseMessage{}
+		err := a.client.postMedia(a.base, "editMessageMedia", true, vals, &res, media[0])
+		return []*Message{res.Result}, err
+	})
 }
 
 // EditMessageReplyMarkup is used to edit only the reply markup of messages.
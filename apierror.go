@@ -18,12 +18,70 @@
 
 package echosphere
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
 
-// APIError represents an error returned by the Telegram API.
-type APIError struct {
+// The sentinel errors matched by the errors returned by the Telegram API, to be used with errors.Is.
+var (
+	ErrUnauthorized          = errors.New("unauthorized")
+	ErrBotBlocked            = errors.New("bot was blocked by the user")
+	ErrBotKicked             = errors.New("bot was kicked from the chat")
+	ErrUserDeactivated       = errors.New("user is deactivated")
+	ErrChatNotFound          = errors.New("chat not found")
+	ErrChatMigrated          = errors.New("group chat was upgraded to a supergroup chat")
+	ErrMessageNotModified    = errors.New("message is not modified")
+	ErrMessageToEditNotFound = errors.New("message to edit not found")
+	ErrMessageNotFound       = errors.New("message not found")
+	ErrNotEnoughRights       = errors.New("not enough rights")
+	ErrInvalidFileID         = errors.New("wrong file identifier")
+	ErrTooManyRequests       = errors.New("too many requests")
+	ErrConflict              = errors.New("conflict with another instance of the bot")
+)
+
+// apiErrors maps the errors returned by the Telegram API to the sentinel errors.
+// An error matches an entry if it has its code, when not 0, and its description
+// contains the given text, when not empty, ignoring the case.
+var apiErrors = []struct {
+	err  error
 	desc string
 	code int
+}{
+	{ErrUnauthorized, "", http.StatusUnauthorized},
+	{ErrTooManyRequests, "", http.StatusTooManyRequests},
+	{ErrConflict, "", http.StatusConflict},
+	{ErrBotBlocked, "bot was blocked by the user", http.StatusForbidden},
+	{ErrBotKicked, "bot was kicked", http.StatusForbidden},
+	{ErrBotKicked, "bot is not a member", http.StatusForbidden},
+	{ErrUserDeactivated, "user is deactivated", http.StatusForbidden},
+	{ErrNotEnoughRights, "not enough rights", 0},
+	{ErrNotEnoughRights, "have no rights", 0},
+	{ErrNotEnoughRights, "need administrator rights", 0},
+	{ErrChatMigrated, "upgraded to a supergroup", http.StatusBadRequest},
+	{ErrChatNotFound, "chat not found", http.StatusBadRequest},
+	{ErrMessageNotModified, "message is not modified", http.StatusBadRequest},
+	{ErrMessageToEditNotFound, "message to edit not found", http.StatusBadRequest},
+	{ErrMessageNotFound, "message to delete not found", http.StatusBadRequest},
+	{ErrMessageNotFound, "message to forward not found", http.StatusBadRequest},
+	{ErrMessageNotFound, "message to copy not found", http.StatusBadRequest},
+	{ErrMessageNotFound, "message not found", http.StatusBadRequest},
+	{ErrInvalidFileID, "file identifier", http.StatusBadRequest},
+	{ErrInvalidFileID, "file_reference", http.StatusBadRequest},
+}
+
+// APIError represents an error returned by the Telegram API.
+type APIError struct {
+	params *ResponseParameters
+	desc   string
+	code   int
 }
 
 // ErrorCode returns the error code received from the Telegram API.
@@ -36,7 +94,107 @@ func (a *APIError) Description() string {
 	return a.desc
 }
 
+// Parameters returns the parameters received along with the error,
+// e.g. the seconds to wait before retrying a request.
+func (a *APIError) Parameters() ResponseParameters {
+	if a.params == nil {
+		return ResponseParameters{}
+	}
+	return *a.params
+}
+
 // Error returns the error string.
 func (a *APIError) Error() string {
 	return fmt.Sprintf("API error: %d %s", a.code, a.desc)
 }
+
+// Is reports whether the error matches the target sentinel error, e.g. ErrBotBlocked.
+func (a *APIError) Is(target error) bool {
+	desc := strings.ToLower(a.desc)
+
+	for _, e := range apiErrors {
+		if e.err == target && (e.code == 0 || e.code == a.code) && strings.Contains(desc, e.desc) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestError is returned when a call to the Telegram API fails before a valid response
+// is received, e.g. because of a network error or of a response which can't be decoded.
+type RequestError struct {
+	// Err is the underlying error.
+	Err error
+	// Endpoint is the name of the method called.
+	Endpoint string
+	// StatusCode is the HTTP status of the response, 0 if none was received.
+	StatusCode int
+}
+
+// Error returns the error string.
+func (r *RequestError) Error() string {
+	if r.StatusCode != 0 {
+		return fmt.Sprintf("%s: HTTP status %d: %v", r.Endpoint, r.StatusCode, r.Err)
+	}
+	return fmt.Sprintf("%s: %v", r.Endpoint, r.Err)
+}
+
+// Unwrap returns the underlying error.
+func (r *RequestError) Unwrap() error {
+	return r.Err
+}
+
+// IsRetryable reports whether the call which returned err can be retried as it is:
+// the errors caused by the rate limits, by the Telegram servers, by the timeouts and by the
+// connections refused or dropped are retryable, while e.g. the TLS and malformed URL errors aren't.
+func IsRetryable(err error) bool {
+	var (
+		apiErr *APIError
+		reqErr *RequestError
+		netErr net.Error
+		dnsErr *net.DNSError
+	)
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &apiErr):
+		return apiErr.code == http.StatusTooManyRequests || apiErr.code >= http.StatusInternalServerError
+	case errors.As(err, &reqErr) && reqErr.StatusCode != 0:
+		return reqErr.StatusCode == http.StatusTooManyRequests || reqErr.StatusCode >= http.StatusInternalServerError
+	case errors.As(err, &netErr) && netErr.Timeout(), errors.As(err, &dnsErr) && dnsErr.IsTemporary:
+		return true
+	default:
+		return isConnError(err)
+	}
+}
+
+// isConnError reports whether err is caused by a connection refused, reset or closed early.
+func isConnError(err error) bool {
+	for _, target := range []error{
+		syscall.ECONNREFUSED,
+		syscall.ECONNRESET,
+		syscall.ECONNABORTED,
+		syscall.EPIPE,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryAfter returns how long to wait before retrying the call which returned err,
+// as requested by Telegram when the rate limits are exceeded.
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+
+	if errors.As(err, &apiErr) {
+		if s := apiErr.Parameters().RetryAfter; s > 0 {
+			return time.Duration(s) * time.Second, true
+		}
+	}
+	return 0, false
+}
@@ -1,6 +1,19 @@
 package echosphere
 
-import "testing"
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
 
 var a APIError
 
@@ -15,3 +28,84 @@ func TestDescription(_ *testing.T) {
 func TestError(_ *testing.T) {
 	_ = a.Error()
 }
+
+func TestAPIErrorIs(t *testing.T) {
+	cases := []struct {
+		target error
+		err    *APIError
+		match  bool
+	}{
+		{ErrBotBlocked, &APIError{code: 403, desc: "Forbidden: bot was blocked by the user"}, true},
+		{ErrBotBlocked, &APIError{code: 400, desc: "Bad Request: bot was blocked by the user"}, false},
+		{ErrChatNotFound, &APIError{code: 400, desc: "Bad Request: chat not found"}, true},
+		{ErrMessageNotModified, &APIError{code: 400, desc: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same"}, true},
+		{ErrMessageToEditNotFound, &APIError{code: 400, desc: "Bad Request: message to edit not found"}, true},
+		{ErrMessageNotFound, &APIError{code: 400, desc: "Bad Request: message to edit not found"}, false},
+		{ErrNotEnoughRights, &APIError{code: 400, desc: "Bad Request: not enough rights to send text messages to the chat"}, true},
+		{ErrTooManyRequests, &APIError{code: 429, desc: "Too Many Requests: retry after 5"}, true},
+		{ErrChatMigrated, &APIError{code: 400, desc: "Bad Request: group chat was upgraded to a supergroup chat"}, true},
+		{ErrInvalidFileID, &APIError{code: 400, desc: "Bad Request: wrong file identifier/HTTP URL specified"}, true},
+		{ErrChatNotFound, &APIError{code: 403, desc: "Forbidden: bot was blocked by the user"}, false},
+	}
+
+	for _, c := range cases {
+		if got := errors.Is(fmt.Errorf("wrapped: %w", c.err), c.target); got != c.match {
+			t.Fatalf("errors.Is(%q, %q): expected %t", c.err, c.target, c.match)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{&APIError{code: 429, desc: "Too Many Requests: retry after 5"}, true},
+		{&APIError{code: 502, desc: "Bad Gateway"}, true},
+		{&APIError{code: 403, desc: "Forbidden: bot was blocked by the user"}, false},
+		{&RequestError{Endpoint: "getMe", StatusCode: 502, Err: errors.New("invalid character '<'")}, true},
+		{&RequestError{Endpoint: "getMe", Err: io.ErrUnexpectedEOF}, true},
+		{&RequestError{Endpoint: "getMe", Err: context.Canceled}, false},
+		{&RequestError{Endpoint: "getMe", Err: &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}}, true},
+		{&RequestError{Endpoint: "getMe", Err: &url.Error{Op: "Get", Err: timeoutError{}}}, true},
+		{&RequestError{Endpoint: "getMe", Err: &url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}}, false},
+		{&RequestError{Endpoint: "getMe", Err: &url.Error{Op: "Get", Err: errors.New("unsupported protocol scheme \"ftp\"")}}, false},
+		{&RequestError{Endpoint: "getMe", Err: &url.Error{Op: "parse", Err: url.EscapeError("%zz")}}, false},
+		{errors.New("other"), false},
+		{nil, false},
+	}
+
+	for _, c := range cases {
+		if got := IsRetryable(c.err); got != c.retryable {
+			t.Fatalf("IsRetryable(%v): expected %t", c.err, c.retryable)
+		}
+	}
+
+	if d, ok := RetryAfter(&APIError{code: 429, params: &ResponseParameters{RetryAfter: 5}}); !ok || d != 5*time.Second {
+		t.Fatalf("unexpected retry after %v", d)
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "<html>Bad Gateway</html>")
+	}))
+	defer srv.Close()
+
+	_, err := NewLocalAPI(srv.URL, "123:token").GetMe()
+
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Endpoint != "getMe" || reqErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected a request error, got %v", err)
+	}
+	if !IsRetryable(err) {
+		t.Fatal("expected the error to be retryable")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
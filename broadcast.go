@@ -0,0 +1,496 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recipients returns the chat IDs of the recipients of a broadcast one by one,
+// with ok set to false when there are no more.
+// It must always return the recipients in the same order, for the broadcast to be resumed.
+type Recipients func() (chatID int64, ok bool, err error)
+
+// RecipientList returns the function passed to NewBroadcast which returns
+// the Recipients iterating over the given chat IDs.
+func RecipientList(chatIDs ...int64) func() Recipients {
+	return func() Recipients {
+		var i int
+
+		return func() (int64, bool, error) {
+			if i >= len(chatIDs) {
+				return 0, false, nil
+			}
+			i++
+			return chatIDs[i-1], true, nil
+		}
+	}
+}
+
+// BroadcastMessage sends the message of a broadcast to the given chat.
+// It's called concurrently by the workers of the broadcast.
+type BroadcastMessage func(api API, chatID int64) error
+
+// BroadcastText returns the BroadcastMessage sending a text message.
+func BroadcastText(text string, opts *MessageOptions) BroadcastMessage {
+	return func(api API, chatID int64) error {
+		_, err := api.SendMessage(text, chatID, opts)
+		return err
+	}
+}
+
+// BroadcastCopy returns the BroadcastMessage sending a copy of an existing message.
+func BroadcastCopy(fromChatID int64, messageID int, opts *CopyOptions) BroadcastMessage {
+	return func(api API, chatID int64) error {
+		_, err := api.CopyMessage(chatID, fromChatID, messageID, opts)
+		return err
+	}
+}
+
+// BroadcastPhoto returns the BroadcastMessage sending a photo.
+// A file to upload is uploaded once, then sent by file_id.
+func BroadcastPhoto(file InputFile, opts *PhotoOptions) BroadcastMessage {
+	return broadcastFile("photo", file, func(api API, file InputFile, chatID int64) (APIResponseMessage, error) {
+		return api.SendPhoto(file, chatID, opts)
+	})
+}
+
+// BroadcastVideo returns the BroadcastMessage sending a video.
+// A file to upload is uploaded once, then sent by file_id.
+func BroadcastVideo(file InputFile, opts *VideoOptions) BroadcastMessage {
+	return broadcastFile("video", file, func(api API, file InputFile, chatID int64) (APIResponseMessage, error) {
+		return api.SendVideo(file, chatID, opts)
+	})
+}
+
+// BroadcastDocument returns the BroadcastMessage sending a document.
+// A file to upload is uploaded once, then sent by file_id.
+func BroadcastDocument(file InputFile, opts *DocumentOptions) BroadcastMessage {
+	return broadcastFile("document", file, func(api API, file InputFile, chatID int64) (APIResponseMessage, error) {
+		return api.SendDocument(file, chatID, opts)
+	})
+}
+
+// broadcastFile returns the BroadcastMessage sending file with send, which is replaced
+// by its file_id after the first successful upload.
+func broadcastFile(kind string, file InputFile, send func(api API, file InputFile, chatID int64) (APIResponseMessage, error)) BroadcastMessage {
+	var mu sync.Mutex
+
+	return func(api API, chatID int64) error {
+		mu.Lock()
+		if !file.isUpload() {
+			f := file
+			mu.Unlock()
+			_, err := send(api, f, chatID)
+			return err
+		}
+
+		// The other workers wait for the upload, to send the file by file_id.
+		defer mu.Unlock()
+		res, err := send(api, file, chatID)
+		if id := messageFileID(kind, res.Result); err == nil && id != "" {
+			file = NewInputFileID(id)
+		}
+		return err
+	}
+}
+
+// BroadcastFailure is the reason why a message couldn't be delivered to a recipient.
+type BroadcastFailure string
+
+// These are all the possible reasons of failure of a BroadcastResult.
+const (
+	FailureBlocked     BroadcastFailure = "blocked"
+	FailureKicked      BroadcastFailure = "kicked"
+	FailureDeactivated BroadcastFailure = "deactivated"
+	FailureNotFound    BroadcastFailure = "not_found"
+	FailureOther       BroadcastFailure = "other"
+)
+
+// failure returns the BroadcastFailure corresponding to err.
+func failure(err error) BroadcastFailure {
+	switch {
+	case errors.Is(err, ErrBotBlocked):
+		return FailureBlocked
+	case errors.Is(err, ErrBotKicked):
+		return FailureKicked
+	case errors.Is(err, ErrUserDeactivated):
+		return FailureDeactivated
+	case errors.Is(err, ErrChatNotFound):
+		return FailureNotFound
+	default:
+		return FailureOther
+	}
+}
+
+// BroadcastResult is the outcome of the delivery of a broadcast to a recipient.
+type BroadcastResult struct {
+	// Err is the error returned by the last attempt, nil if the message was delivered.
+	Err error
+	// Failure is the reason of the failure, empty if the message was delivered.
+	Failure BroadcastFailure
+	// ChatID is the chat ID of the recipient.
+	ChatID int64
+	// Index is the position of the recipient in the Recipients.
+	Index int
+}
+
+// BroadcastProgress counts the recipients processed by a broadcast, including the previous runs.
+type BroadcastProgress struct {
+	Failures map[BroadcastFailure]int `json:"failures,omitempty"`
+	Sent     int                      `json:"sent"`
+	Failed   int                      `json:"failed"`
+}
+
+// BroadcastCheckpoint is the state of a broadcast saved to resume it.
+type BroadcastCheckpoint struct {
+	// Progress is the progress of the broadcast so far.
+	Progress BroadcastProgress `json:"progress"`
+	// Done are the indices of the recipients after Next which have already been processed.
+	Done []int `json:"done,omitempty"`
+	// Next is the index of the first recipient not processed yet.
+	Next int `json:"next"`
+	// Completed reports whether all the recipients have been processed.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// BroadcastStore persists the checkpoints of the broadcasts, identified by their IDs.
+type BroadcastStore interface {
+	// Load returns the checkpoint of the broadcast with the given ID, if any.
+	Load(id string) (BroadcastCheckpoint, bool, error)
+	// Save stores the checkpoint of the broadcast with the given ID.
+	Save(id string, c BroadcastCheckpoint) error
+}
+
+// fileBroadcastStore is a BroadcastStore keeping each checkpoint in a JSON file.
+type fileBroadcastStore struct {
+	dir string
+}
+
+// NewFileBroadcastStore returns a BroadcastStore which keeps the checkpoint of each broadcast
+// in a JSON file named after its ID in the given directory.
+func NewFileBroadcastStore(dir string) BroadcastStore {
+	return fileBroadcastStore{dir: dir}
+}
+
+// Load implements BroadcastStore.
+func (f fileBroadcastStore) Load(id string) (c BroadcastCheckpoint, ok bool, err error) {
+	b, err := os.ReadFile(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return c, false, nil
+	}
+	if err != nil {
+		return c, false, err
+	}
+	return c, true, json.Unmarshal(b, &c)
+}
+
+// Save implements BroadcastStore, replacing the file atomically.
+func (f fileBroadcastStore) Save(id string, c BroadcastCheckpoint) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(id))
+}
+
+func (f fileBroadcastStore) path(id string) string {
+	return filepath.Join(f.dir, filepath.Base(id)+".json")
+}
+
+// Broadcast sends the same message to many recipients through several workers.
+// The requests go through the rate limiters of the API like any other, the recipients
+// are retried after the time requested by Telegram when the limits are exceeded,
+// and after an increasing delay on the network and server errors.
+// With a BroadcastStore, the progress is saved after each recipient and when the broadcast is
+// interrupted, so that running it again with the same ID resumes it without re-sending
+// the message to the recipients already processed.
+// The delivery is at least once: a recipient may receive the message twice if the process
+// stops between the sending and the saving of the checkpoint.
+type Broadcast struct {
+	store      BroadcastStore
+	recipients func() Recipients
+	message    BroadcastMessage
+	onResult   func(BroadcastResult)
+	done       map[int]bool
+	api        API
+	id         string
+	progress   BroadcastProgress
+	next       int
+	workers    int
+	retries    int
+	saveEvery  int
+	unsaved    int
+	mu         sync.Mutex
+}
+
+// NewBroadcast returns a new Broadcast identified by id, sending message to the Recipients
+// returned by recipients, which is called at the start of each Run to iterate from the first one.
+func NewBroadcast(api API, id string, recipients func() Recipients, message BroadcastMessage) *Broadcast {
+	return &Broadcast{
+		api:        api,
+		id:         id,
+		recipients: recipients,
+		message:    message,
+		workers:    8,
+		retries:    3,
+		saveEvery:  1,
+	}
+}
+
+// Workers sets the number of messages sent concurrently, 8 by default.
+func (b *Broadcast) Workers(n int) *Broadcast {
+	if n > 0 {
+		b.workers = n
+	}
+	return b
+}
+
+// Retries sets how many times a recipient is retried after a retryable error, 3 by default.
+func (b *Broadcast) Retries(n int) *Broadcast {
+	b.retries = n
+	return b
+}
+
+// Store sets the BroadcastStore where the checkpoints are saved every n processed recipients,
+// after each one if n is less than 1.
+// A greater n makes fewer writes, but up to n-1 recipients may receive the message twice
+// if the process stops before the next checkpoint; an interrupted Run still saves it.
+func (b *Broadcast) Store(s BroadcastStore, n int) *Broadcast {
+	if n < 1 {
+		n = 1
+	}
+	b.store, b.saveEvery = s, n
+	return b
+}
+
+// OnResult sets the function called with the outcome of each recipient, e.g. to unsubscribe
+// the users who blocked the bot. It's called concurrently by the workers.
+func (b *Broadcast) OnResult(fn func(BroadcastResult)) *Broadcast {
+	b.onResult = fn
+	return b
+}
+
+// Progress returns the progress of the broadcast, which can be called while it runs.
+func (b *Broadcast) Progress() BroadcastProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.progress.copy()
+}
+
+// Run sends the message to all the recipients not processed yet and returns the final progress.
+// Running the broadcast again resumes it from the checkpoint in its store, or without a store
+// from where the previous Run stopped; once completed, it does nothing.
+// When ctx is canceled it stops sending, waits for the messages being sent and saves the checkpoint,
+// returning the error of ctx.
+func (b *Broadcast) Run(ctx context.Context) (BroadcastProgress, error) {
+	cp, err := b.load()
+	if err != nil || cp.Completed {
+		return cp.Progress, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan BroadcastResult)
+	)
+
+	for i := 0; i < b.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				b.deliver(ctx, job)
+			}
+		}()
+	}
+
+	err = b.dispatch(ctx, jobs, b.recipients(), cp)
+	close(jobs)
+	wg.Wait()
+
+	// The workers may have given up on some recipients if ctx was canceled after the dispatch.
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if serr := b.save(err == nil); err == nil {
+		err = serr
+	}
+	return b.progress.copy(), err
+}
+
+// dispatch sends the recipients not processed yet to the workers.
+func (b *Broadcast) dispatch(ctx context.Context, jobs chan<- BroadcastResult, recipients Recipients, cp BroadcastCheckpoint) error {
+	for i := 0; ; i++ {
+		chatID, ok, err := recipients()
+		if err != nil || !ok {
+			return err
+		}
+
+		if i < cp.Next || b.isDone(i) {
+			continue
+		}
+
+		select {
+		case jobs <- BroadcastResult{ChatID: chatID, Index: i}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver sends the message to the recipient of job, retrying on the retryable errors.
+// The recipient isn't recorded as processed if ctx is canceled before the message is sent.
+func (b *Broadcast) deliver(ctx context.Context, job BroadcastResult) {
+	for attempt := 0; ; attempt++ {
+		job.Err = b.message(b.api, job.ChatID)
+		if job.Err == nil || !IsRetryable(job.Err) || attempt >= b.retries {
+			break
+		}
+
+		wait, ok := RetryAfter(job.Err)
+		if !ok {
+			wait = time.Second << attempt
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	if job.Err != nil {
+		job.Failure = failure(job.Err)
+	}
+	b.complete(job)
+
+	if b.onResult != nil {
+		b.onResult(job)
+	}
+}
+
+// complete records the outcome of a recipient, saving the checkpoint when due.
+func (b *Broadcast) complete(res BroadcastResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if res.Err == nil {
+		b.progress.Sent++
+	} else {
+		b.progress.Failed++
+		if b.progress.Failures == nil {
+			b.progress.Failures = make(map[BroadcastFailure]int)
+		}
+		b.progress.Failures[res.Failure]++
+	}
+
+	b.done[res.Index] = true
+	for b.done[b.next] {
+		delete(b.done, b.next)
+		b.next++
+	}
+
+	if b.unsaved++; b.unsaved >= b.saveEvery {
+		// A failed save is retried with the next one.
+		b.save(false)
+	}
+}
+
+func (b *Broadcast) isDone(i int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.done[i]
+}
+
+// load restores the state of the broadcast from its checkpoint, if any.
+// Without a store, the state left by the previous Run is kept.
+func (b *Broadcast) load() (cp BroadcastCheckpoint, err error) {
+	b.mu.Lock()
+	if b.store == nil && b.done != nil {
+		cp = BroadcastCheckpoint{Progress: b.progress.copy(), Next: b.next}
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Unlock()
+
+	if b.store != nil {
+		if cp, _, err = b.store.Load(b.id); err != nil {
+			return
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.progress = cp.Progress.copy()
+	b.next = cp.Next
+	b.done = make(map[int]bool, len(cp.Done))
+	for _, i := range cp.Done {
+		b.done[i] = true
+	}
+	return
+}
+
+// save saves the checkpoint of the broadcast, if it has a store.
+// It must be called with b.mu locked.
+func (b *Broadcast) save(completed bool) error {
+	if b.store == nil {
+		return nil
+	}
+
+	cp := BroadcastCheckpoint{
+		Progress:  b.progress.copy(),
+		Next:      b.next,
+		Completed: completed,
+	}
+	for i := range b.done {
+		cp.Done = append(cp.Done, i)
+	}
+	sort.Ints(cp.Done)
+
+	b.unsaved = 0
+	return b.store.Save(b.id, cp)
+}
+
+func (p BroadcastProgress) copy() BroadcastProgress {
+	if p.Failures != nil {
+		f := make(map[BroadcastFailure]int, len(p.Failures))
+		for k, v := range p.Failures {
+			f[k] = v
+		}
+		p.Failures = f
+	}
+	return p
+}
@@ -0,0 +1,200 @@
+package echosphere
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type broadcastTarget struct {
+	errs map[int64]error
+	sent map[int64]int
+	mu   sync.Mutex
+}
+
+func (b *broadcastTarget) send(_ API, chatID int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err, ok := b.errs[chatID]; ok {
+		// The server errors fail only once.
+		if e, isAPI := err.(*APIError); isAPI && e.code >= 500 {
+			delete(b.errs, chatID)
+		}
+		return err
+	}
+	b.sent[chatID]++
+	return nil
+}
+
+func TestBroadcast(t *testing.T) {
+	var (
+		results = make(map[int64]BroadcastFailure)
+		mu      sync.Mutex
+		target  = &broadcastTarget{
+			sent: make(map[int64]int),
+			errs: map[int64]error{
+				2: &APIError{code: 403, desc: "Forbidden: bot was blocked by the user"},
+				3: &APIError{code: 403, desc: "Forbidden: user is deactivated"},
+				4: &APIError{code: 400, desc: "Bad Request: chat not found"},
+				5: &APIError{code: 502, desc: "Bad Gateway"},
+			},
+		}
+	)
+
+	b := NewBroadcast(NewAPI("token"), "news", RecipientList(1, 2, 3, 4, 5, 6), target.send).
+		Workers(3).
+		OnResult(func(r BroadcastResult) {
+			mu.Lock()
+			results[r.ChatID] = r.Failure
+			mu.Unlock()
+		})
+
+	p, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Sent != 3 || p.Failed != 3 {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if results[2] != FailureBlocked || results[3] != FailureDeactivated || results[4] != FailureNotFound || results[5] != "" {
+		t.Fatalf("unexpected results %v", results)
+	}
+	if target.sent[5] != 1 {
+		t.Fatal("expected the server error to be retried")
+	}
+}
+
+func TestBroadcastResume(t *testing.T) {
+	var (
+		recipients = make([]int64, 50)
+		store      = NewFileBroadcastStore(t.TempDir())
+		target     = &broadcastTarget{sent: make(map[int64]int)}
+	)
+	for i := range recipients {
+		recipients[i] = int64(i + 1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var n int
+	interrupted := func(api API, chatID int64) error {
+		target.mu.Lock()
+		if n++; n == 20 {
+			cancel()
+		}
+		target.mu.Unlock()
+		return target.send(api, chatID)
+	}
+
+	p, err := NewBroadcast(NewAPI("token"), "resume", RecipientList(recipients...), interrupted).
+		Workers(4).
+		Store(store, 5).
+		Run(ctx)
+	if err != context.Canceled {
+		t.Fatalf("expected the broadcast to be canceled, got %v", err)
+	}
+	if p.Sent < 20 || p.Sent == len(recipients) {
+		t.Fatalf("unexpected progress after the interruption %+v", p)
+	}
+
+	b := NewBroadcast(NewAPI("token"), "resume", RecipientList(recipients...), target.send).Store(store, 5)
+	if p, err = b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p.Sent != len(recipients) {
+		t.Fatalf("expected all the recipients to be counted, got %+v", p)
+	}
+
+	for _, id := range recipients {
+		if target.sent[id] != 1 {
+			t.Fatalf("expected chat %d to receive the message once, got %d", id, target.sent[id])
+		}
+	}
+
+	// A completed broadcast isn't sent again.
+	if _, err := b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cp, ok, _ := store.Load("resume"); !ok || !cp.Completed || target.sent[1] != 1 {
+		t.Fatalf("unexpected checkpoint %+v", cp)
+	}
+}
+
+func TestBroadcastSaveEach(t *testing.T) {
+	store := NewFileBroadcastStore(t.TempDir())
+
+	// Each recipient is saved before the next one is sent, so that none would be sent twice after a crash.
+	check := func(_ API, chatID int64) error {
+		if cp, _, err := store.Load("each"); err != nil || cp.Next != int(chatID-1) {
+			t.Errorf("chat %d: unexpected checkpoint %+v %v", chatID, cp, err)
+		}
+		return nil
+	}
+
+	if _, err := NewBroadcast(NewAPI("token"), "each", RecipientList(1, 2, 3, 4), check).
+		Workers(1).
+		Store(store, 0).
+		Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBroadcastRunAgain(t *testing.T) {
+	for _, store := range []BroadcastStore{nil, NewFileBroadcastStore(t.TempDir())} {
+		var (
+			n      int
+			target = &broadcastTarget{sent: make(map[int64]int)}
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		interrupted := func(api API, chatID int64) error {
+			target.mu.Lock()
+			if n++; n == 4 {
+				cancel()
+			}
+			target.mu.Unlock()
+			return target.send(api, chatID)
+		}
+
+		b := NewBroadcast(NewAPI("token"), "again", RecipientList(1, 2, 3, 4, 5, 6, 7, 8), interrupted).Workers(2).Store(store, 0)
+		if _, err := b.Run(ctx); err != context.Canceled {
+			t.Fatalf("expected the broadcast to be canceled, got %v", err)
+		}
+
+		// The same Broadcast resumes with the recipients not processed yet.
+		p, err := b.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Sent != 8 {
+			t.Fatalf("store %v: expected all the recipients to be counted, got %+v", store, p)
+		}
+		for id := int64(1); id <= 8; id++ {
+			if target.sent[id] != 1 {
+				t.Fatalf("store %v: expected chat %d to receive the message once, got %d", store, id, target.sent[id])
+			}
+		}
+	}
+}
+
+func TestBroadcastFile(t *testing.T) {
+	var files []InputFile
+
+	msg := broadcastFile("photo", NewInputFileBytes("a.jpg", []byte("a")), func(_ API, file InputFile, _ int64) (APIResponseMessage, error) {
+		files = append(files, file)
+		return APIResponseMessage{Result: &Message{Photo: []*PhotoSize{{FileID: "small"}, {FileID: "large"}}}}, nil
+	})
+
+	for i := int64(0); i < 3; i++ {
+		if err := msg(NewAPI("token"), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !files[0].isUpload() || files[1].id != "large" || files[2].id != "large" {
+		t.Fatalf("expected the file to be uploaded once, got %+v", files)
+	}
+}
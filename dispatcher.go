@@ -23,10 +23,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 )
 
 // Bot is the interface that must be implemented by your definition of
@@ -49,6 +49,7 @@ type Dispatcher struct {
 	updates    chan *Update
 	httpServer *http.Server
 	api        API
+	groups     mediaGroups
 	mu         sync.Mutex
 }
 
@@ -128,24 +129,62 @@ func (d *Dispatcher) PollOptions(dropPendingUpdates bool, opts UpdateOptions) er
 	}
 }
 
-func (d *Dispatcher) instance(chatID int64) Bot {
+func (d *Dispatcher) instance(chatID int64) (bot Bot, isNew bool) {
 	bot, ok := d.sessionMap[chatID]
 	if !ok {
+		d.log().Debug("new session", "chat_id", chatID)
 		bot = d.newBot(chatID)
 		d.mu.Lock()
 		d.sessionMap[chatID] = bot
 		d.mu.Unlock()
 	}
-	return bot
+	return bot, !ok
 }
 
 func (d *Dispatcher) listen() {
 	for update := range d.updates {
-		bot := d.instance(update.ChatID())
-		go bot.Update(update)
+		chatID := update.ChatID()
+		if d.groups.add(update, d.updates) {
+			continue
+		}
+		d.log().Debug("update", "update_id", update.ID, "chat_id", chatID)
+
+		// When a group is upgraded to a supergroup, its session moves to the new chat ID,
+		// where the updates reporting the migration are dispatched too.
+		if from, to, ok := migration(update); ok {
+			if d.migrate(from, to) {
+				d.log().Debug("chat migrated to a supergroup", "from_chat_id", from, "to_chat_id", to)
+			}
+			chatID = to
+		}
+
+		bot, isNew := d.instance(chatID)
+		go d.run(bot, update, chatID, isNew)
 	}
 }
 
+// run calls the Update method of bot and reports it to the Observer.
+func (d *Dispatcher) run(bot Bot, update *Update, chatID int64, isNew bool) {
+	ev := UpdateEvent{
+		Type:       updateType(update),
+		ChatID:     chatID,
+		UpdateID:   update.ID,
+		NewSession: isNew,
+	}
+
+	start := time.Now()
+	defer func() {
+		ev.Duration = time.Since(start)
+		if ev.Panic = recover(); ev.Panic != nil {
+			d.api.client.obs().ObserveUpdate(ev)
+			panic(ev.Panic)
+		}
+		d.api.client.obs().ObserveUpdate(ev)
+	}()
+
+	bot.Update(update)
+}
+
 // ListenWebhook is a wrapper function for ListenWebhookOptions.
 func (d *Dispatcher) ListenWebhook(webhookURL string) error {
 	return d.ListenWebhookOptions(webhookURL, false, nil)
@@ -178,6 +217,33 @@ func (d *Dispatcher) ListenWebhookOptions(webhookURL string, dropPendingUpdates
 	return http.ListenAndServe(fmt.Sprintf(":%s", u.Port()), nil)
 }
 
+// SetLogger sets the Logger used by the Dispatcher and its API in place of the default one.
+// It should be called before starting to receive the updates.
+func (d *Dispatcher) SetLogger(l Logger) {
+	d.api = d.api.WithLogger(l)
+}
+
+// SetObserver sets the Observer of the updates handled by the Dispatcher and of the calls of its API,
+// in place of the default one.
+// It should be called before starting to receive the updates.
+func (d *Dispatcher) SetObserver(o Observer) {
+	d.api = d.api.WithObserver(o)
+}
+
+// SetMediaGroupWindow enables the aggregation of the albums: the updates with the messages
+// sharing the same MediaGroupID are buffered for the given window since the first one arrives,
+// then Update is called once with the first of them, whose MediaGroup method returns the whole album.
+// Telegram sends the items of an album within a short time, a window of about a second is usually enough.
+// A window of 0, the default, disables the aggregation.
+// It should be called before starting to receive the updates.
+func (d *Dispatcher) SetMediaGroupWindow(window time.Duration) {
+	d.groups.window = window
+}
+
+func (d *Dispatcher) log() Logger {
+	return d.api.client.log()
+}
+
 // SetHTTPServer allows to set a custom http.Server for ListenWebhook and ListenWebhookOptions.
 func (d *Dispatcher) SetHTTPServer(s *http.Server) {
 	d.httpServer = s
@@ -190,12 +256,12 @@ func (d *Dispatcher) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	jsn, err := readRequest(r)
 	if err != nil {
-		log.Println("echosphere.Dispatcher", "HandleWebhook", err)
+		d.log().Error("can't read the webhook request", "error", redact(err.Error()))
 		return
 	}
 
 	if err := json.Unmarshal(jsn, &update); err != nil {
-		log.Println("echosphere.Dispatcher", "HandleWebhook", err)
+		d.log().Error("can't decode the update", "error", err)
 		return
 	}
 
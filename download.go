@@ -0,0 +1,256 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrFileTooLarge is returned when a file being downloaded exceeds the maximum size
+// set with SetMaxDownloadSize.
+var ErrFileTooLarge = errors.New("file exceeds the maximum download size")
+
+// downloadRetries is the number of times an interrupted download is resumed.
+const downloadRetries = 3
+
+// SetMaxDownloadSize sets the maximum size in bytes of the files downloaded through the API.
+// A size of 0, the default, disables the check.
+func SetMaxDownloadSize(size int64) {
+	lclient.Lock()
+	lclient.maxDownload = size
+	lclient.Unlock()
+}
+
+// DownloadFileTo streams the file corresponding to the given filePath to w and returns
+// the number of bytes written.
+// Interrupted transfers are resumed with HTTP range requests, so w receives each byte only once.
+// This function is callable for at least 1 hour since the call to GetFile.
+func (a API) DownloadFileTo(ctx context.Context, filePath string, w io.Writer) (int64, error) {
+	return a.ResumeDownload(ctx, filePath, w, 0)
+}
+
+// ResumeDownload is like DownloadFileTo, but skips the first offset bytes of the file,
+// useful to complete a partial file left by a previous run.
+// The returned count doesn't include the skipped bytes.
+func (a API) ResumeDownload(ctx context.Context, filePath string, w io.Writer, offset int64) (int64, error) {
+	if a.isLocalFile(filePath) {
+		return a.copyLocal(filePath, w, offset)
+	}
+	return a.client.downloadTo(ctx, a.fileURL(filePath), w, offset)
+}
+
+// OpenFile returns a reader streaming the file corresponding to the given filePath.
+// The caller must close the reader when done.
+func (a API) OpenFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if a.isLocalFile(filePath) {
+		return a.openLocal(filePath, 0)
+	}
+
+	res, err := a.client.openRange(ctx, a.fileURL(filePath), 0)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// DownloadByFileID calls GetFile with the given fileID and streams the file to w,
+// returning its information.
+func (a API) DownloadByFileID(ctx context.Context, fileID string, w io.Writer) (*File, error) {
+	res, err := a.GetFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Result == nil {
+		return nil, fmt.Errorf("no file returned for file ID %s", fileID)
+	}
+
+	_, err = a.DownloadFileTo(ctx, res.Result.FilePath, w)
+	return res.Result, err
+}
+
+func (a API) fileURL(filePath string) string {
+	return a.fileBase + strings.TrimPrefix(filePath, "/")
+}
+
+// downloadTo streams the resource at url to w, starting from offset and resuming
+// the transfer with range requests if it's interrupted.
+func (c client) downloadTo(ctx context.Context, url string, w io.Writer, offset int64) (total int64, err error) {
+	for attempt := 0; ; attempt++ {
+		var (
+			n   int64
+			res *http.Response
+		)
+
+		res, err = c.openRange(ctx, url, offset)
+		if err == nil {
+			n, err = io.Copy(w, res.Body)
+			res.Body.Close()
+		}
+
+		offset += n
+		total += n
+
+		if err == nil || !isResumable(err) || attempt == downloadRetries || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// openRange requests the resource at url starting from offset and returns the response,
+// whose body is limited by the maximum download size.
+func (c client) openRange(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, resumableError{err}
+	}
+
+	var size = res.ContentLength
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// The server ignored the range, so the bytes already received are skipped.
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, res.Body, offset); err != nil {
+				res.Body.Close()
+				return nil, resumableError{err}
+			}
+		}
+
+	case http.StatusPartialContent:
+		if start, ok := parseContentRange(res.Header.Get("Content-Range")); !ok || start != offset {
+			res.Body.Close()
+			return nil, fmt.Errorf("unexpected content range %q", res.Header.Get("Content-Range"))
+		}
+		if size >= 0 {
+			size += offset
+		}
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The whole file has already been received.
+		res.Body.Close()
+		res.Body = http.NoBody
+		return res, nil
+
+	default:
+		defer res.Body.Close()
+		return nil, downloadError(res)
+	}
+
+	if max := c.maxDownloadSize(); max > 0 {
+		if size > max {
+			res.Body.Close()
+			return nil, ErrFileTooLarge
+		}
+		res.Body = &limitedBody{ReadCloser: res.Body, left: max - offset}
+	}
+
+	res.Body = &resumableBody{res.Body}
+	return res, nil
+}
+
+func (c client) maxDownloadSize() int64 {
+	c.RLock()
+	defer c.RUnlock()
+	return c.maxDownload
+}
+
+// downloadError returns the error described by the body of a failed download response.
+func downloadError(res *http.Response) error {
+	var base APIResponseBase
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	if err := json.Unmarshal(body, &base); err == nil && !base.Ok && base.ErrorCode != 0 {
+		return check(base)
+	}
+	return &APIError{code: res.StatusCode, desc: http.StatusText(res.StatusCode)}
+}
+
+// limitedBody returns ErrFileTooLarge when more than left bytes are read from it.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+func (l *limitedBody) Read(b []byte) (n int, err error) {
+	n, err = l.ReadCloser.Read(b)
+	if l.left -= int64(n); l.left < 0 {
+		return n + int(l.left), ErrFileTooLarge
+	}
+	return
+}
+
+// resumableBody marks the errors occurred while reading the body as resumable.
+type resumableBody struct {
+	io.ReadCloser
+}
+
+func (r *resumableBody) Read(b []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(b)
+	if err != nil && err != io.EOF && !errors.Is(err, ErrFileTooLarge) {
+		err = resumableError{err}
+	}
+	return
+}
+
+// resumableError wraps the transport errors after which a download can be resumed.
+type resumableError struct {
+	error
+}
+
+func (r resumableError) Unwrap() error {
+	return r.error
+}
+
+func isResumable(err error) bool {
+	var r resumableError
+	return errors.As(err, &r)
+}
+
+// parseContentRange returns the start of the range in a Content-Range header value.
+func parseContentRange(s string) (int64, bool) {
+	s, ok := cutPrefix(s, "bytes ")
+	if !ok {
+		return 0, false
+	}
+
+	i := strings.IndexByte(s, '-')
+	if i < 0 {
+		return 0, false
+	}
+
+	start, err := strconv.ParseInt(s[:i], 10, 64)
+	return start, err == nil
+}
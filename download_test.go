@@ -0,0 +1,105 @@
+package echosphere
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var downloadContent = strings.Repeat("0123456789", 1000)
+
+// flakyServer serves downloadContent, interrupting the first response halfway.
+func flakyServer(ranges *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+
+		if len(*ranges) == 1 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(downloadContent)))
+			w.Write([]byte(downloadContent[:len(downloadContent)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(downloadContent))
+	}))
+}
+
+func TestDownloadResume(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		ranges []string
+	)
+
+	srv := flakyServer(&ranges)
+	defer srv.Close()
+
+	n, err := newClient().downloadTo(context.Background(), srv.URL, &buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != int64(len(downloadContent)) || buf.String() != downloadContent {
+		t.Fatalf("expected %d bytes, got %d", len(downloadContent), n)
+	}
+
+	expected := "bytes=" + strconv.Itoa(len(downloadContent)/2) + "-"
+	if len(ranges) != 2 || ranges[1] != expected {
+		t.Fatalf("expected a second request with range %q, got %q", expected, ranges)
+	}
+}
+
+func TestDownloadOffset(t *testing.T) {
+	var buf bytes.Buffer
+
+	// The server ignores the range header, so the client must skip the first bytes itself.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(downloadContent))
+	}))
+	defer srv.Close()
+
+	if _, err := newClient().downloadTo(context.Background(), srv.URL, &buf, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != downloadContent[10:] {
+		t.Fatalf("unexpected content %q", buf.String()[:10])
+	}
+}
+
+func TestDownloadMaxSize(t *testing.T) {
+	var buf bytes.Buffer
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(downloadContent))
+	}))
+	defer srv.Close()
+
+	c := newClient()
+	c.maxDownload = 100
+
+	if _, err := c.downloadTo(context.Background(), srv.URL, &buf, 0); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestDownloadError(t *testing.T) {
+	var buf bytes.Buffer
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+	}))
+	defer srv.Close()
+
+	_, err := newClient().downloadTo(context.Background(), srv.URL, &buf, 0)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != http.StatusNotFound {
+		t.Fatalf("expected a 404 APIError, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echospheretest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf16"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+// lastID is the last ID assigned to the messages and callback queries built by the helpers.
+var lastID int64
+
+// Harness drives the bots created by a NewBotFn with synthetic updates, one session
+// per chat like the Dispatcher, while keeping track of the updates still being handled.
+// It only creates the sessions and passes them the updates: unlike the Dispatcher,
+// it doesn't move the sessions on the supergroup migrations, doesn't aggregate the
+// media groups and doesn't notify any Observer.
+type Harness struct {
+	sessions map[int64]echosphere.Bot
+	newBot   echosphere.NewBotFn
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	updateID int
+}
+
+// NewHarness returns a new Harness creating the sessions with newBot.
+func NewHarness(newBot echosphere.NewBotFn) *Harness {
+	return &Harness{
+		sessions: make(map[int64]echosphere.Bot),
+		newBot:   newBot,
+	}
+}
+
+// Send passes u to the Bot associated with its chat ID, creating it if needed,
+// and returns without waiting for the update to be handled.
+// The update is assigned the next update ID if it has none.
+func (h *Harness) Send(u *echosphere.Update) {
+	h.mu.Lock()
+	if u.ID == 0 {
+		h.updateID++
+		u.ID = h.updateID
+	} else if u.ID > h.updateID {
+		h.updateID = u.ID
+	}
+
+	chatID := u.ChatID()
+	bot, ok := h.sessions[chatID]
+	if !ok {
+		bot = h.newBot(chatID)
+		h.sessions[chatID] = bot
+	}
+	h.wg.Add(1)
+	h.mu.Unlock()
+
+	go func() {
+		defer h.wg.Done()
+		bot.Update(u)
+	}()
+}
+
+// Wait blocks until all the updates sent so far have been handled.
+func (h *Harness) Wait() {
+	h.wg.Wait()
+}
+
+// Deliver sends the updates in order, waiting for each one to be handled before sending the next.
+func (h *Harness) Deliver(updates ...*echosphere.Update) {
+	for _, u := range updates {
+		h.Send(u)
+		h.Wait()
+	}
+}
+
+// Session returns the Bot associated with the given chat ID, if any.
+func (h *Harness) Session(chatID int64) (echosphere.Bot, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bot, ok := h.sessions[chatID]
+	return bot, ok
+}
+
+// DelSession deletes the Bot associated with the given chat ID,
+// so that the next update for it creates a new one.
+func (h *Harness) DelSession(chatID int64) {
+	h.mu.Lock()
+	delete(h.sessions, chatID)
+	h.mu.Unlock()
+}
+
+// NewMessage returns a message with the given text sent by the user with the given ID
+// in the chat with the given ID. The commands at the beginning of the text are marked
+// with a bot_command entity, as Telegram does.
+func NewMessage(chatID, userID int64, text string) *echosphere.Message {
+	msg := &echosphere.Message{
+		ID:   int(atomic.AddInt64(&lastID, 1)),
+		Date: int(time.Now().Unix()),
+		From: newUser(userID),
+		Chat: echosphere.Chat{ID: chatID, Type: chatType(chatID)},
+		Text: text,
+	}
+
+	if strings.HasPrefix(text, "/") {
+		cmd, _, _ := strings.Cut(text, " ")
+		msg.Entities = []*echosphere.MessageEntity{{Type: echosphere.BotCommandEntity, Length: len(utf16.Encode([]rune(cmd)))}}
+	}
+	return msg
+}
+
+// NewTextUpdate returns an update with a text message sent by the user with the given ID
+// in the chat with the given ID.
+func NewTextUpdate(chatID, userID int64, text string) *echosphere.Update {
+	return &echosphere.Update{Message: NewMessage(chatID, userID, text)}
+}
+
+// NewCallbackUpdate returns an update with a callback query with the given data, originated
+// by the user with the given ID pressing a button of the message with the given ID in the chat.
+func NewCallbackUpdate(chatID, userID int64, messageID int, data string) *echosphere.Update {
+	msg := &echosphere.Message{
+		ID:   messageID,
+		Date: int(time.Now().Unix()),
+		Chat: echosphere.Chat{ID: chatID, Type: chatType(chatID)},
+	}
+
+	return &echosphere.Update{
+		CallbackQuery: &echosphere.CallbackQuery{
+			ID:           fmt.Sprintf("callback-%d", atomic.AddInt64(&lastID, 1)),
+			From:         newUser(userID),
+			Message:      msg,
+			ChatInstance: fmt.Sprintf("%d", chatID),
+			Data:         data,
+		},
+	}
+}
+
+// NewPhotoUpdate returns an update with a photo, identified by the given file_id,
+// sent with the given caption by the user with the given ID in the chat with the given ID.
+func NewPhotoUpdate(chatID, userID int64, fileID, caption string) *echosphere.Update {
+	msg := NewMessage(chatID, userID, "")
+	msg.Caption = caption
+	msg.Photo = []*echosphere.PhotoSize{{
+		FileID:       fileID,
+		FileUniqueID: fileID,
+		Width:        1280,
+		Height:       720,
+	}}
+	return &echosphere.Update{Message: msg}
+}
+
+func newUser(id int64) *echosphere.User {
+	return &echosphere.User{ID: id, FirstName: fmt.Sprintf("User %d", id)}
+}
@@ -0,0 +1,76 @@
+package echospheretest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+// counter is a bot replying with the number of updates received in its chat.
+type counter struct {
+	api    echosphere.API
+	chatID int64
+	n      int
+	mu     sync.Mutex
+}
+
+func (c *counter) Update(u *echosphere.Update) {
+	c.mu.Lock()
+	c.n++
+	n := c.n
+	c.mu.Unlock()
+
+	switch {
+	case u.CallbackQuery != nil:
+		c.api.AnswerCallbackQuery(u.CallbackQuery.ID, nil)
+	case u.Message != nil && len(u.Message.Photo) > 0:
+		c.api.SendMessage("nice photo: "+u.Message.Caption, c.chatID, nil)
+	case u.Message != nil:
+		c.api.SendMessage(u.Message.Text+" "+string(rune('0'+n)), c.chatID, nil)
+	}
+}
+
+func TestHarness(t *testing.T) {
+	srv, api := newAPI(t)
+
+	h := NewHarness(func(chatID int64) echosphere.Bot {
+		return &counter{api: api, chatID: chatID}
+	})
+
+	start := NewTextUpdate(400, 400, "/start now")
+	if e := start.Message.Entities; len(e) != 1 || e[0].Type != echosphere.BotCommandEntity || e[0].Length != 6 {
+		t.Fatalf("unexpected entities %+v", e)
+	}
+
+	h.Deliver(
+		start,
+		NewPhotoUpdate(400, 400, "photo-id", "cat"),
+		NewCallbackUpdate(400, 400, 1, "button"),
+	)
+
+	msgs := srv.Messages(400)
+	if len(msgs) != 2 || msgs[0].Text != "/start now 1" || msgs[1].Text != "nice photo: cat" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+	if len(srv.Calls("answerCallbackQuery")) != 1 {
+		t.Fatal("expected the callback query to be answered")
+	}
+
+	for i := 0; i < 5; i++ {
+		h.Send(NewTextUpdate(-401, 400, "hi"))
+	}
+	h.Wait()
+
+	if bot, ok := h.Session(-401); !ok || bot.(*counter).n != 5 {
+		t.Fatalf("expected 5 updates handled by the session, got %+v", bot)
+	}
+	if n := len(srv.Messages(-401)); n != 5 {
+		t.Fatalf("expected 5 messages, got %d", n)
+	}
+
+	h.DelSession(-401)
+	if _, ok := h.Session(-401); ok {
+		t.Fatal("expected the session to be deleted")
+	}
+}
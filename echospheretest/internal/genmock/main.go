@@ -0,0 +1,223 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Command genmock generates the MockAPI type of echospheretest from the BotAPI interface.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+)
+
+// method is a method of the BotAPI interface.
+type method struct {
+	name     string
+	params   []string
+	types    []string
+	results  []string
+	variadic bool
+}
+
+func main() {
+	var (
+		src = flag.String("src", "../interfaces.go", "file declaring the BotAPI interface")
+		out = flag.String("o", "mock_api.go", "output file")
+	)
+	flag.Parse()
+
+	methods, err := parse(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := format.Source(generate(methods))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parse returns the methods of the BotAPI interface, in the order they're declared.
+func parse(path string) ([]method, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ifaces := make(map[string]*ast.InterfaceType)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			if it, ok := ts.Type.(*ast.InterfaceType); ok {
+				ifaces[ts.Name.Name] = it
+			}
+		}
+		return true
+	})
+
+	root, ok := ifaces["BotAPI"]
+	if !ok {
+		return nil, fmt.Errorf("BotAPI not found in %s", path)
+	}
+	return collect(ifaces, root)
+}
+
+// collect returns the methods of it, including the ones of the embedded interfaces.
+func collect(ifaces map[string]*ast.InterfaceType, it *ast.InterfaceType) (ret []method, err error) {
+	for _, field := range it.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.Ident:
+			embedded, ok := ifaces[t.Name]
+			if !ok {
+				return nil, fmt.Errorf("interface %s not found", t.Name)
+			}
+
+			methods, err := collect(ifaces, embedded)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, methods...)
+
+		case *ast.FuncType:
+			m := method{name: field.Names[0].Name}
+
+			for _, p := range t.Params.List {
+				typ := qualify(p.Type)
+				if _, ok := p.Type.(*ast.Ellipsis); ok {
+					m.variadic = true
+				}
+
+				for _, n := range p.Names {
+					m.params = append(m.params, n.Name)
+					m.types = append(m.types, typ)
+				}
+			}
+
+			for _, r := range t.Results.List {
+				m.results = append(m.results, qualify(r.Type))
+			}
+			ret = append(ret, m)
+		}
+	}
+	return
+}
+
+// qualify returns the source of the type expression with the identifiers
+// of the echosphere package qualified.
+func qualify(expr ast.Expr) string {
+	var buf bytes.Buffer
+
+	printer.Fprint(&buf, token.NewFileSet(), qualifyExpr(expr))
+	return buf.String()
+}
+
+func qualifyExpr(expr ast.Expr) ast.Expr {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(t.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent("echosphere"), Sel: ast.NewIdent(t.Name)}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualifyExpr(t.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: t.Len, Elt: qualifyExpr(t.Elt)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: qualifyExpr(t.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualifyExpr(t.Key), Value: qualifyExpr(t.Value)}
+	}
+	return expr
+}
+
+func generate(methods []method) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprint(&b, `// Code generated by genmock. DO NOT EDIT.
+
+package echospheretest
+
+import (
+	"context"
+	"io"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+var _ echosphere.BotAPI = (*MockAPI)(nil)
+
+// MockAPI is a mock implementation of echosphere.BotAPI which records every call.
+// Each method calls the function in the field named after it with the Func suffix, if set,
+// otherwise it returns the zero values.
+type MockAPI struct {
+`)
+	for _, m := range methods {
+		fmt.Fprintf(&b, "\t%sFunc func(%s) (%s)\n", m.name, strings.Join(m.types, ", "), strings.Join(m.results, ", "))
+	}
+	fmt.Fprint(&b, "\tcalls\n}\n")
+
+	for _, m := range methods {
+		var (
+			params  = make([]string, len(m.params))
+			results = make([]string, len(m.results))
+			args    = strings.Join(m.params, ", ")
+		)
+
+		for i := range m.params {
+			params[i] = m.params[i] + " " + m.types[i]
+		}
+		for i := range m.results {
+			results[i] = fmt.Sprintf("r%d %s", i, m.results[i])
+		}
+		if m.variadic {
+			args += "..."
+		}
+
+		fmt.Fprintf(&b, `
+// %[1]s records the call and calls %[1]sFunc.
+func (m *MockAPI) %[1]s(%[2]s) (%[3]s) {
+	m.record(%[1]q%[4]s)
+	if m.%[1]sFunc != nil {
+		return m.%[1]sFunc(%[5]s)
+	}
+	return
+}
+`, m.name, strings.Join(params, ", "), strings.Join(results, ", "), prefixed(m.params), args)
+	}
+
+	return b.Bytes()
+}
+
+func prefixed(params []string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(params, ", ")
+}
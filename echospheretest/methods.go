@@ -0,0 +1,769 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echospheretest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+// mediaFields maps the methods sending a single media to the name of their file parameter.
+var mediaFields = map[string]string{
+	"sendPhoto":     "photo",
+	"sendAudio":     "audio",
+	"sendDocument":  "document",
+	"sendVideo":     "video",
+	"sendAnimation": "animation",
+	"sendVoice":     "voice",
+	"sendVideoNote": "video_note",
+	"sendSticker":   "sticker",
+}
+
+// methods returns the built-in implementations of the Bot API methods.
+func (s *Server) methods() map[string]handler {
+	m := map[string]handler{
+		"getMe":                  s.getMe,
+		"logOut":                 returnTrue,
+		"close":                  returnTrue,
+		"getUpdates":             s.getUpdates,
+		"setWebhook":             s.setWebhook,
+		"deleteWebhook":          s.deleteWebhook,
+		"getWebhookInfo":         s.getWebhookInfo,
+		"sendMessage":            s.sendMessage,
+		"forwardMessage":         s.forwardMessage,
+		"copyMessage":            s.copyMessage,
+		"sendMediaGroup":         s.sendMediaGroup,
+		"sendLocation":           s.sendLocation,
+		"sendDice":               s.sendDice,
+		"sendChatAction":         returnTrue,
+		"answerCallbackQuery":    returnTrue,
+		"answerInlineQuery":      returnTrue,
+		"editMessageText":        s.editMessageText,
+		"editMessageCaption":     s.editMessageCaption,
+		"editMessageMedia":       s.editMessageMedia,
+		"editMessageReplyMarkup": s.editMessageReplyMarkup,
+		"deleteMessage":          s.deleteMessage,
+		"deleteMessages":         s.deleteMessages,
+		"getFile":                s.getFile,
+		"getChat":                s.getChat,
+	}
+
+	for method, field := range mediaFields {
+		field := field
+		m[method] = func(_ context.Context, req Request) (any, error) {
+			return s.sendMedia(req, field)
+		}
+	}
+	return m
+}
+
+func returnTrue(context.Context, Request) (any, error) {
+	return true, nil
+}
+
+func (s *Server) getMe(context.Context, Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bot, nil
+}
+
+func (s *Server) getUpdates(ctx context.Context, req Request) (any, error) {
+	var (
+		offset, _  = strconv.Atoi(req.Params.Get("offset"))
+		limit, _   = strconv.Atoi(req.Params.Get("limit"))
+		timeout, _ = strconv.Atoi(req.Params.Get("timeout"))
+		deadline   = time.NewTimer(time.Duration(timeout) * time.Second)
+	)
+	defer deadline.Stop()
+
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	for {
+		s.mu.Lock()
+		if s.webhook.url != "" {
+			s.mu.Unlock()
+			return nil, &Error{
+				Code:        http.StatusConflict,
+				Description: "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first",
+			}
+		}
+
+		// The updates before the offset are confirmed and forgotten.
+		if offset > 0 {
+			i := 0
+			for i < len(s.updates) && s.updates[i].ID < offset {
+				i++
+			}
+			s.updates = s.updates[i:]
+		}
+
+		pending := s.updates
+		if len(pending) > limit {
+			pending = pending[:limit]
+		}
+		ret := append(make([]echosphere.Update, 0, len(pending)), pending...)
+		notify := s.notify
+		s.mu.Unlock()
+
+		if len(ret) > 0 || timeout <= 0 {
+			return ret, nil
+		}
+
+		select {
+		case <-notify:
+		case <-deadline.C:
+			return ret, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.done:
+			return ret, nil
+		}
+	}
+}
+
+func (s *Server) setWebhook(_ context.Context, req Request) (any, error) {
+	u, err := parseURL(req.Params.Get("url"))
+	if err != nil {
+		return nil, badRequest("bad webhook: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhook = webhook{url: u, secret: req.Params.Get("secret_token")}
+	if req.Params.Get("drop_pending_updates") == "true" {
+		s.updates = nil
+	}
+	return true, nil
+}
+
+func (s *Server) deleteWebhook(_ context.Context, req Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhook = webhook{}
+	if req.Params.Get("drop_pending_updates") == "true" {
+		s.updates = nil
+	}
+	return true, nil
+}
+
+func (s *Server) getWebhookInfo(context.Context, Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return echosphere.WebhookInfo{
+		URL:                s.webhook.url,
+		PendingUpdateCount: len(s.updates),
+	}, nil
+}
+
+func (s *Server) sendMessage(_ context.Context, req Request) (any, error) {
+	text, entities, err := parseText(req.Params.Get("text"), req.Params.Get("parse_mode"), req.Params.Get("entities"))
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, badRequest("message text is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	m.Text, m.Entities = text, entities
+	return s.store(m), nil
+}
+
+func (s *Server) forwardMessage(_ context.Context, req Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, err := s.sourceMessage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	copyContent(m, src)
+	return s.store(m), nil
+}
+
+func (s *Server) copyMessage(_ context.Context, req Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, err := s.sourceMessage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	copyContent(m, src)
+	if req.Params.Has("caption") {
+		m.Caption = req.Params.Get("caption")
+	}
+	s.store(m)
+	return echosphere.MessageID{MessageID: m.ID}, nil
+}
+
+func (s *Server) sendMedia(req Request, field string) (any, error) {
+	caption, entities, err := parseText(req.Params.Get("caption"), req.Params.Get("parse_mode"), req.Params.Get("caption_entities"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.inputFile(req, field, req.Params.Get(field))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	m.Caption, m.CaptionEntities = caption, entities
+	attach(m, field, f)
+	return s.store(m), nil
+}
+
+// inputMedia is the JSON representation of the InputMedia* types.
+type inputMedia struct {
+	Type            string                      `json:"type"`
+	Media           string                      `json:"media"`
+	Caption         string                      `json:"caption"`
+	ParseMode       string                      `json:"parse_mode"`
+	CaptionEntities []*echosphere.MessageEntity `json:"caption_entities"`
+}
+
+func (s *Server) sendMediaGroup(_ context.Context, req Request) (any, error) {
+	var media []inputMedia
+
+	if err := json.Unmarshal([]byte(req.Params.Get("media")), &media); err != nil {
+		return nil, badRequest("can't parse media JSON object")
+	}
+	if len(media) < 2 || len(media) > 10 {
+		return nil, badRequest("media must include 2-10 items")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		ret     = make([]*echosphere.Message, 0, len(media))
+		groupID = strconv.FormatInt(time.Now().UnixNano(), 10)
+	)
+
+	for _, im := range media {
+		m, err := s.newMediaMessage(req, im)
+		if err != nil {
+			return nil, err
+		}
+		m.MediaGroupID = groupID
+		ret = append(ret, m)
+	}
+
+	for i, m := range ret {
+		ret[i] = s.store(m)
+	}
+	return ret, nil
+}
+
+// newMediaMessage returns a new message containing im.
+func (s *Server) newMediaMessage(req Request, im inputMedia) (*echosphere.Message, error) {
+	caption, entities, err := parseText(im.Caption, im.ParseMode, "")
+	if err != nil {
+		return nil, err
+	}
+	if im.ParseMode == "" {
+		entities = im.CaptionEntities
+	}
+
+	f, err := s.inputFile(req, im.Type, im.Media)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	m.Caption, m.CaptionEntities = caption, entities
+	attach(m, im.Type, f)
+	return m, nil
+}
+
+func (s *Server) sendLocation(_ context.Context, req Request) (any, error) {
+	lat, err1 := strconv.ParseFloat(req.Params.Get("latitude"), 64)
+	lon, err2 := strconv.ParseFloat(req.Params.Get("longitude"), 64)
+	if err1 != nil || err2 != nil {
+		return nil, badRequest("wrong coordinates")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	m.Location = &echosphere.Location{Latitude: lat, Longitude: lon}
+	return s.store(m), nil
+}
+
+func (s *Server) sendDice(_ context.Context, req Request) (any, error) {
+	emoji := req.Params.Get("emoji")
+	if emoji == "" {
+		emoji = "🎲"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.newMessage(req)
+	if err != nil {
+		return nil, err
+	}
+	m.Dice = &echosphere.Dice{Emoji: emoji, Value: m.ID%6 + 1}
+	return s.store(m), nil
+}
+
+func (s *Server) editMessageText(_ context.Context, req Request) (any, error) {
+	text, entities, err := parseText(req.Params.Get("text"), req.Params.Get("parse_mode"), req.Params.Get("entities"))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.edit(req, func(m *echosphere.Message) {
+		m.Text, m.Entities = text, entities
+	})
+}
+
+func (s *Server) editMessageCaption(_ context.Context, req Request) (any, error) {
+	caption, entities, err := parseText(req.Params.Get("caption"), req.Params.Get("parse_mode"), req.Params.Get("caption_entities"))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.edit(req, func(m *echosphere.Message) {
+		m.Caption, m.CaptionEntities = caption, entities
+	})
+}
+
+func (s *Server) editMessageMedia(_ context.Context, req Request) (any, error) {
+	var im inputMedia
+
+	if err := json.Unmarshal([]byte(req.Params.Get("media")), &im); err != nil {
+		return nil, badRequest("can't parse InputMedia JSON object")
+	}
+
+	s.mu.Lock()
+	f, err := s.inputFile(req, im.Type, im.Media)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	caption, entities, err := parseText(im.Caption, im.ParseMode, "")
+	if err != nil {
+		return nil, err
+	}
+	if im.ParseMode == "" {
+		entities = im.CaptionEntities
+	}
+
+	return s.edit(req, func(m *echosphere.Message) {
+		m.Photo, m.Audio, m.Document, m.Video, m.Animation = nil, nil, nil, nil, nil
+		m.Caption, m.CaptionEntities = caption, entities
+		attach(m, im.Type, f)
+	})
+}
+
+func (s *Server) editMessageReplyMarkup(_ context.Context, req Request) (any, error) {
+	return s.edit(req, func(*echosphere.Message) {})
+}
+
+// edit applies fn and the reply markup in req to the message identified by req.
+func (s *Server) edit(req Request, fn func(*echosphere.Message)) (any, error) {
+	markup, err := parseMarkup(req.Params.Get("reply_markup"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The inline messages aren't stored, so their edits always succeed.
+	if req.Params.Get("inline_message_id") != "" {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.findMessage(req.Params.Get("chat_id"), req.Params.Get("message_id"))
+	if err != nil {
+		return nil, badRequest("message to edit not found")
+	}
+
+	edited := *m
+	fn(&edited)
+	edited.ReplyMarkup = markup
+
+	if string(mustMarshal(m)) == string(mustMarshal(edited)) {
+		return nil, badRequest("message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+	}
+
+	edited.EditDate = int(time.Now().Unix())
+	*m = edited
+	ret := *m
+	return &ret, nil
+}
+
+func (s *Server) deleteMessage(_ context.Context, req Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.findMessage(req.Params.Get("chat_id"), req.Params.Get("message_id"))
+	if err != nil {
+		return nil, badRequest("message to delete not found")
+	}
+
+	delete(s.chats[m.Chat.ID].messages, m.ID)
+	return true, nil
+}
+
+func (s *Server) deleteMessages(_ context.Context, req Request) (any, error) {
+	var ids []int
+
+	if err := json.Unmarshal([]byte(req.Params.Get("message_ids")), &ids); err != nil {
+		return nil, badRequest("can't parse message identifiers")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if m, err := s.findMessage(req.Params.Get("chat_id"), strconv.Itoa(id)); err == nil {
+			delete(s.chats[m.Chat.ID].messages, m.ID)
+		}
+	}
+	return true, nil
+}
+
+func (s *Server) getFile(_ context.Context, req Request) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[req.Params.Get("file_id")]
+	if !ok {
+		return nil, badRequest("invalid file_id")
+	}
+	return f.info, nil
+}
+
+func (s *Server) getChat(_ context.Context, req Request) (any, error) {
+	id, err := strconv.ParseInt(req.Params.Get("chat_id"), 10, 64)
+	if err != nil {
+		return nil, badRequest("chat not found")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chat(id).info, nil
+}
+
+// chat returns the chat with the given ID, creating it if needed.
+func (s *Server) chat(id int64) *chat {
+	c, ok := s.chats[id]
+	if !ok {
+		c = &chat{
+			info:     echosphere.Chat{ID: id, Type: chatType(id)},
+			messages: make(map[int]*echosphere.Message),
+		}
+		s.chats[id] = c
+	}
+	return c
+}
+
+// chatType returns the type of chat suggested by the ID.
+func chatType(id int64) string {
+	switch {
+	case id > 0:
+		return "private"
+	case id < -1000000000000:
+		return "supergroup"
+	default:
+		return "group"
+	}
+}
+
+// newMessage returns a new message sent by the bot in the chat identified by req,
+// with the common options applied.
+func (s *Server) newMessage(req Request) (*echosphere.Message, error) {
+	id, err := strconv.ParseInt(req.Params.Get("chat_id"), 10, 64)
+	if err != nil {
+		return nil, badRequest("chat not found")
+	}
+
+	markup, err := parseMarkup(req.Params.Get("reply_markup"))
+	if err != nil {
+		return nil, err
+	}
+
+	c := s.chat(id)
+	c.next++
+
+	bot := s.bot
+	m := &echosphere.Message{
+		ID:          c.next,
+		Chat:        c.info,
+		From:        &bot,
+		Date:        int(time.Now().Unix()),
+		ReplyMarkup: markup,
+	}
+	m.ThreadID, _ = strconv.Atoi(req.Params.Get("message_thread_id"))
+
+	if rp := req.Params.Get("reply_parameters"); rp != "" {
+		var params echosphere.ReplyParameters
+
+		if err := json.Unmarshal([]byte(rp), &params); err != nil {
+			return nil, badRequest("can't parse reply parameters JSON object")
+		}
+
+		if params.MessageID != 0 {
+			reply, ok := c.messages[params.MessageID]
+			if !ok && !params.AllowSendingWithoutReply {
+				return nil, badRequest("message to be replied not found")
+			}
+			if ok {
+				r := *reply
+				m.ReplyToMessage = &r
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// store saves m in its chat and returns a copy of it.
+func (s *Server) store(m *echosphere.Message) *echosphere.Message {
+	s.chat(m.Chat.ID).messages[m.ID] = m
+	ret := *m
+	return &ret
+}
+
+// sourceMessage returns the message identified by the from_chat_id and message_id parameters.
+func (s *Server) sourceMessage(req Request) (*echosphere.Message, error) {
+	m, err := s.findMessage(req.Params.Get("from_chat_id"), req.Params.Get("message_id"))
+	if err != nil {
+		return nil, badRequest("message to forward not found")
+	}
+	return m, nil
+}
+
+func (s *Server) findMessage(chatID, messageID string) (*echosphere.Message, error) {
+	cid, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	mid, err := strconv.Atoi(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := s.chats[cid]; ok {
+		if m, ok := c.messages[mid]; ok {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("message %d not found in chat %d", mid, cid)
+}
+
+// copyContent copies the content of src into m.
+func copyContent(m, src *echosphere.Message) {
+	m.Text, m.Entities = src.Text, src.Entities
+	m.Caption, m.CaptionEntities = src.Caption, src.CaptionEntities
+	m.Photo, m.Audio, m.Document = src.Photo, src.Audio, src.Document
+	m.Video, m.Animation, m.Voice = src.Video, src.Animation, src.Voice
+	m.VideoNote, m.Sticker, m.Location = src.VideoNote, src.Sticker, src.Location
+	m.Dice, m.Contact, m.Poll = src.Dice, src.Contact, src.Poll
+}
+
+// inputFile returns the file described by value, which is either a file_id, a URL
+// or a reference to a file uploaded in req, creating it if needed.
+func (s *Server) inputFile(req Request, kind, value string) (*file, error) {
+	if name, ok := cutPrefix(value, "attach://"); ok {
+		value = ""
+		if up, ok := req.Files[name]; ok {
+			return s.addFile(kind+"s", up.Name, up.Data), nil
+		}
+	}
+
+	if value == "" {
+		if up, ok := req.Files[kind]; ok {
+			return s.addFile(kind+"s", up.Name, up.Data), nil
+		}
+		return nil, badRequest("there is no %s in the request", kind)
+	}
+
+	if f, ok := s.files[value]; ok {
+		return f, nil
+	}
+
+	if u, err := parseURL(value); err == nil {
+		return s.addFile(kind+"s", filepath.Base(u), nil), nil
+	}
+	return nil, badRequest("wrong file identifier/HTTP URL specified")
+}
+
+// addFile stores a new file in the given directory.
+func (s *Server) addFile(dir, name string, data []byte) *file {
+	s.nfiles++
+
+	f := &file{
+		data: data,
+		name: name,
+		info: echosphere.File{
+			FileID:       fmt.Sprintf("file%d", s.nfiles),
+			FileUniqueID: fmt.Sprintf("unique%d", s.nfiles),
+			FilePath:     fmt.Sprintf("%s/file_%d%s", dir, s.nfiles, filepath.Ext(name)),
+			FileSize:     int64(len(data)),
+		},
+	}
+
+	s.files[f.info.FileID] = f
+	s.paths[f.info.FilePath] = f
+	return f
+}
+
+// attach sets f as the media of the given kind in m.
+func attach(m *echosphere.Message, kind string, f *file) {
+	var (
+		id     = f.info.FileID
+		unique = f.info.FileUniqueID
+		size   = f.info.FileSize
+	)
+
+	switch kind {
+	case "photo":
+		m.Photo = []*echosphere.PhotoSize{{FileID: id, FileUniqueID: unique, FileSize: int(size), Width: 800, Height: 600}}
+	case "audio":
+		m.Audio = &echosphere.Audio{FileID: id, FileUniqueID: unique, FileName: f.name, FileSize: size}
+	case "video":
+		m.Video = &echosphere.Video{FileID: id, FileUniqueID: unique, FileName: f.name, FileSize: size}
+	case "animation":
+		m.Animation = &echosphere.Animation{FileID: id, FileUniqueID: unique, FileName: f.name, FileSize: size}
+	case "voice":
+		m.Voice = &echosphere.Voice{FileID: id, FileUniqueID: unique, FileSize: size}
+	case "video_note":
+		m.VideoNote = &echosphere.VideoNote{FileID: id, FileUniqueID: unique, FileSize: int(size)}
+	case "sticker":
+		m.Sticker = &echosphere.Sticker{FileID: id, FileUniqueID: unique, FileSize: int(size), Type: "regular"}
+	default:
+		m.Document = &echosphere.Document{FileID: id, FileUniqueID: unique, FileName: f.name, FileSize: size}
+	}
+}
+
+// parseText returns the text and its entities, either parsed according to parseMode
+// or taken from the JSON encoded entities.
+func parseText(text, parseMode, entities string) (string, []*echosphere.MessageEntity, error) {
+	var (
+		parsed []echosphere.MessageEntity
+		err    error
+	)
+
+	switch echosphere.ParseMode(parseMode) {
+	case echosphere.HTML:
+		text, parsed, err = echosphere.ParseHTML(text)
+	case echosphere.MarkdownV2:
+		text, parsed, err = echosphere.ParseMarkdownV2(text)
+	default:
+		if entities == "" {
+			return text, nil, nil
+		}
+
+		var ret []*echosphere.MessageEntity
+		if err := json.Unmarshal([]byte(entities), &ret); err != nil {
+			return "", nil, badRequest("can't parse entities JSON object")
+		}
+		return text, ret, nil
+	}
+
+	if err != nil {
+		return "", nil, badRequest("%v", err)
+	}
+
+	ret := make([]*echosphere.MessageEntity, len(parsed))
+	for i := range parsed {
+		ret[i] = &parsed[i]
+	}
+	return text, ret, nil
+}
+
+// parseMarkup returns the inline keyboard in the JSON encoded reply markup, if any.
+func parseMarkup(markup string) (*echosphere.InlineKeyboardMarkup, error) {
+	var kbd echosphere.InlineKeyboardMarkup
+
+	if markup == "" {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal([]byte(markup), &kbd); err != nil {
+		return nil, badRequest("can't parse reply keyboard markup JSON object")
+	}
+
+	if len(kbd.InlineKeyboard) == 0 {
+		return nil, nil
+	}
+	return &kbd, nil
+}
+
+// parseURL checks that u is an absolute URL and returns it.
+func parseURL(u string) (string, error) {
+	if !strings.Contains(u, "://") {
+		return "", fmt.Errorf("invalid URL %q", u)
+	}
+	return u, nil
+}
+
+func mustMarshal(v any) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
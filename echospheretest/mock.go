@@ -0,0 +1,68 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echospheretest
+
+import "sync"
+
+//go:generate go run ./internal/genmock -src ../interfaces.go -o mock_api.go
+
+// Call is a call to a method of MockAPI.
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// calls records the calls to the methods of MockAPI.
+type calls struct {
+	list []Call
+	mu   sync.Mutex
+}
+
+func (c *calls) record(method string, args ...any) {
+	c.mu.Lock()
+	c.list = append(c.list, Call{Method: method, Args: args})
+	c.mu.Unlock()
+}
+
+// Calls returns all the calls made so far, in order.
+func (c *calls) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.list...)
+}
+
+// CallsTo returns the calls made so far to the given method, in order.
+func (c *calls) CallsTo(method string) (ret []Call) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, call := range c.list {
+		if call.Method == method {
+			ret = append(ret, call)
+		}
+	}
+	return
+}
+
+// Reset forgets the calls made so far.
+func (c *calls) Reset() {
+	c.mu.Lock()
+	c.list = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,1309 @@
+// Code generated by genmock. DO NOT EDIT.
+
+package echospheretest
+
+import (
+	"context"
+	"io"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+var _ echosphere.BotAPI = (*MockAPI)(nil)
+
+// MockAPI is a mock implementation of echosphere.BotAPI which records every call.
+// Each method calls the function in the field named after it with the Func suffix, if set,
+// otherwise it returns the zero values.
+type MockAPI struct {
+	GetUpdatesFunc                        func(*echosphere.UpdateOptions) (echosphere.APIResponseUpdate, error)
+	SetWebhookFunc                        func(string, bool, *echosphere.WebhookOptions) (echosphere.APIResponseBase, error)
+	DeleteWebhookFunc                     func(bool) (echosphere.APIResponseBase, error)
+	GetWebhookInfoFunc                    func() (echosphere.APIResponseWebhook, error)
+	GetMeFunc                             func() (echosphere.APIResponseUser, error)
+	LogOutFunc                            func() (echosphere.APIResponseBool, error)
+	CloseFunc                             func() (echosphere.APIResponseBool, error)
+	SetMyCommandsFunc                     func(*echosphere.CommandOptions, ...echosphere.BotCommand) (echosphere.APIResponseBool, error)
+	DeleteMyCommandsFunc                  func(*echosphere.CommandOptions) (echosphere.APIResponseBool, error)
+	GetMyCommandsFunc                     func(*echosphere.CommandOptions) (echosphere.APIResponseCommands, error)
+	SetMyNameFunc                         func(string, string) (echosphere.APIResponseBool, error)
+	GetMyNameFunc                         func(string) (echosphere.APIResponseBotName, error)
+	SetMyDescriptionFunc                  func(string, string) (echosphere.APIResponseBool, error)
+	GetMyDescriptionFunc                  func(string) (echosphere.APIResponseBotDescription, error)
+	SetMyShortDescriptionFunc             func(string, string) (echosphere.APIResponseBool, error)
+	GetMyShortDescriptionFunc             func(string) (echosphere.APIResponseBotShortDescription, error)
+	SetMyDefaultAdministratorRightsFunc   func(*echosphere.SetMyDefaultAdministratorRightsOptions) (echosphere.APIResponseBool, error)
+	GetMyDefaultAdministratorRightsFunc   func(*echosphere.GetMyDefaultAdministratorRightsOptions) (echosphere.APIResponseChatAdministratorRights, error)
+	SetChatMenuButtonFunc                 func(*echosphere.SetChatMenuButtonOptions) (echosphere.APIResponseBool, error)
+	GetChatMenuButtonFunc                 func(*echosphere.GetChatMenuButtonOptions) (echosphere.APIResponseMenuButton, error)
+	GetBusinessConnectionFunc             func(string) (echosphere.APIResponseBusinessConnection, error)
+	SendMessageFunc                       func(string, int64, *echosphere.MessageOptions) (echosphere.APIResponseMessage, error)
+	SendLongMessageFunc                   func(string, int64, *echosphere.MessageOptions) ([]echosphere.APIResponseMessage, error)
+	SendLongCaptionFunc                   func(int64, string, echosphere.CaptionFunc, *echosphere.MessageOptions) ([]echosphere.APIResponseMessage, error)
+	ForwardMessageFunc                    func(int64, int64, int, *echosphere.ForwardOptions) (echosphere.APIResponseMessage, error)
+	ForwardMessagesFunc                   func(int64, int64, []int, *echosphere.ForwardOptions) (echosphere.APIResponseMessageIDs, error)
+	CopyMessageFunc                       func(int64, int64, int, *echosphere.CopyOptions) (echosphere.APIResponseMessageID, error)
+	CopyMessagesFunc                      func(int64, int64, []int, *echosphere.CopyMessagesOptions) (echosphere.APIResponseMessageIDs, error)
+	SendPhotoFunc                         func(echosphere.InputFile, int64, *echosphere.PhotoOptions) (echosphere.APIResponseMessage, error)
+	SendAudioFunc                         func(echosphere.InputFile, int64, *echosphere.AudioOptions) (echosphere.APIResponseMessage, error)
+	SendDocumentFunc                      func(echosphere.InputFile, int64, *echosphere.DocumentOptions) (echosphere.APIResponseMessage, error)
+	SendVideoFunc                         func(echosphere.InputFile, int64, *echosphere.VideoOptions) (echosphere.APIResponseMessage, error)
+	SendAnimationFunc                     func(echosphere.InputFile, int64, *echosphere.AnimationOptions) (echosphere.APIResponseMessage, error)
+	SendVoiceFunc                         func(echosphere.InputFile, int64, *echosphere.VoiceOptions) (echosphere.APIResponseMessage, error)
+	SendVideoNoteFunc                     func(echosphere.InputFile, int64, *echosphere.VideoNoteOptions) (echosphere.APIResponseMessage, error)
+	SendMediaGroupFunc                    func(int64, []echosphere.GroupableInputMedia, *echosphere.MediaGroupOptions) (echosphere.APIResponseMessageArray, error)
+	SendAlbumFunc                         func(int64, []echosphere.GroupableInputMedia, *echosphere.AlbumOptions) ([]*echosphere.Message, error)
+	SendLocationFunc                      func(int64, float64, float64, *echosphere.LocationOptions) (echosphere.APIResponseMessage, error)
+	EditMessageLiveLocationFunc           func(echosphere.MessageIDOptions, float64, float64, *echosphere.EditLocationOptions) (echosphere.APIResponseMessage, error)
+	StopMessageLiveLocationFunc           func(echosphere.MessageIDOptions, *echosphere.MessageReplyMarkup) (echosphere.APIResponseMessage, error)
+	SendVenueFunc                         func(int64, float64, float64, string, string, *echosphere.VenueOptions) (echosphere.APIResponseMessage, error)
+	SendContactFunc                       func(string, string, int64, *echosphere.ContactOptions) (echosphere.APIResponseMessage, error)
+	SendPollFunc                          func(int64, string, []echosphere.InputPollOption, *echosphere.PollOptions) (echosphere.APIResponseMessage, error)
+	SendDiceFunc                          func(int64, echosphere.DiceEmoji, *echosphere.BaseOptions) (echosphere.APIResponseMessage, error)
+	SendChatActionFunc                    func(echosphere.ChatAction, int64, *echosphere.ChatActionOptions) (echosphere.APIResponseBool, error)
+	SetMessageReactionFunc                func(int64, int, *echosphere.MessageReactionOptions) (echosphere.APIResponseBool, error)
+	EditMessageTextFunc                   func(string, echosphere.MessageIDOptions, *echosphere.MessageTextOptions) (echosphere.APIResponseMessage, error)
+	EditMessageCaptionFunc                func(echosphere.MessageIDOptions, *echosphere.MessageCaptionOptions) (echosphere.APIResponseMessage, error)
+	EditMessageMediaFunc                  func(echosphere.MessageIDOptions, echosphere.InputMedia, *echosphere.MessageReplyMarkup) (echosphere.APIResponseMessage, error)
+	EditMessageReplyMarkupFunc            func(echosphere.MessageIDOptions, *echosphere.MessageReplyMarkup) (echosphere.APIResponseMessage, error)
+	StopPollFunc                          func(int64, int, *echosphere.MessageReplyMarkup) (echosphere.APIResponsePoll, error)
+	DeleteMessageFunc                     func(int64, int) (echosphere.APIResponseBase, error)
+	DeleteMessagesFunc                    func(int64, []int) (echosphere.APIResponseBool, error)
+	GetUserProfilePhotosFunc              func(int64, *echosphere.UserProfileOptions) (echosphere.APIResponseUserProfile, error)
+	GetFileFunc                           func(string) (echosphere.APIResponseFile, error)
+	DownloadFileFunc                      func(string) ([]byte, error)
+	DownloadFileToFunc                    func(context.Context, string, io.Writer) (int64, error)
+	ResumeDownloadFunc                    func(context.Context, string, io.Writer, int64) (int64, error)
+	OpenFileFunc                          func(context.Context, string) (io.ReadCloser, error)
+	DownloadByFileIDFunc                  func(context.Context, string, io.Writer) (*echosphere.File, error)
+	BanChatMemberFunc                     func(int64, int64, *echosphere.BanOptions) (echosphere.APIResponseBool, error)
+	UnbanChatMemberFunc                   func(int64, int64, *echosphere.UnbanOptions) (echosphere.APIResponseBool, error)
+	RestrictChatMemberFunc                func(int64, int64, echosphere.ChatPermissions, *echosphere.RestrictOptions) (echosphere.APIResponseBool, error)
+	PromoteChatMemberFunc                 func(int64, int64, *echosphere.PromoteOptions) (echosphere.APIResponseBool, error)
+	SetChatAdministratorCustomTitleFunc   func(int64, int64, string) (echosphere.APIResponseBool, error)
+	BanChatSenderChatFunc                 func(int64, int64) (echosphere.APIResponseBool, error)
+	UnbanChatSenderChatFunc               func(int64, int64) (echosphere.APIResponseBool, error)
+	SetChatPermissionsFunc                func(int64, echosphere.ChatPermissions, *echosphere.ChatPermissionsOptions) (echosphere.APIResponseBool, error)
+	ExportChatInviteLinkFunc              func(int64) (echosphere.APIResponseString, error)
+	CreateChatInviteLinkFunc              func(int64, *echosphere.InviteLinkOptions) (echosphere.APIResponseInviteLink, error)
+	EditChatInviteLinkFunc                func(int64, string, *echosphere.InviteLinkOptions) (echosphere.APIResponseInviteLink, error)
+	RevokeChatInviteLinkFunc              func(int64, string) (echosphere.APIResponseInviteLink, error)
+	ApproveChatJoinRequestFunc            func(int64, int64) (echosphere.APIResponseBool, error)
+	DeclineChatJoinRequestFunc            func(int64, int64) (echosphere.APIResponseBool, error)
+	SetChatPhotoFunc                      func(echosphere.InputFile, int64) (echosphere.APIResponseBool, error)
+	DeleteChatPhotoFunc                   func(int64) (echosphere.APIResponseBool, error)
+	SetChatTitleFunc                      func(int64, string) (echosphere.APIResponseBool, error)
+	SetChatDescriptionFunc                func(int64, string) (echosphere.APIResponseBool, error)
+	PinChatMessageFunc                    func(int64, int, *echosphere.PinMessageOptions) (echosphere.APIResponseBool, error)
+	UnpinChatMessageFunc                  func(int64, int) (echosphere.APIResponseBool, error)
+	UnpinAllChatMessagesFunc              func(int64) (echosphere.APIResponseBool, error)
+	LeaveChatFunc                         func(int64) (echosphere.APIResponseBool, error)
+	GetChatFunc                           func(int64) (echosphere.APIResponseChat, error)
+	GetChatAdministratorsFunc             func(int64) (echosphere.APIResponseAdministrators, error)
+	GetChatMemberCountFunc                func(int64) (echosphere.APIResponseInteger, error)
+	GetChatMemberFunc                     func(int64, int64) (echosphere.APIResponseChatMember, error)
+	SetChatStickerSetFunc                 func(int64, string) (echosphere.APIResponseBool, error)
+	DeleteChatStickerSetFunc              func(int64) (echosphere.APIResponseBool, error)
+	GetUserChatBoostsFunc                 func(int64, int64) (echosphere.APIResponseUserChatBoosts, error)
+	CreateForumTopicFunc                  func(int64, string, *echosphere.CreateTopicOptions) (echosphere.APIResponseForumTopic, error)
+	EditForumTopicFunc                    func(int64, int64, *echosphere.EditTopicOptions) (echosphere.APIResponseBool, error)
+	CloseForumTopicFunc                   func(int64, int64) (echosphere.APIResponseBool, error)
+	ReopenForumTopicFunc                  func(int64, int64) (echosphere.APIResponseBool, error)
+	DeleteForumTopicFunc                  func(int64, int64) (echosphere.APIResponseBool, error)
+	UnpinAllForumTopicMessagesFunc        func(int64, int64) (echosphere.APIResponseBool, error)
+	EditGeneralForumTopicFunc             func(int64, string) (echosphere.APIResponseBool, error)
+	CloseGeneralForumTopicFunc            func(int64) (echosphere.APIResponseBool, error)
+	ReopenGeneralForumTopicFunc           func(int64) (echosphere.APIResponseBool, error)
+	HideGeneralForumTopicFunc             func(int64) (echosphere.APIResponseBool, error)
+	UnhideGeneralForumTopicFunc           func(int64) (echosphere.APIResponseBool, error)
+	UnpinAllGeneralForumTopicMessagesFunc func(int64) (echosphere.APIResponseBool, error)
+	GetForumTopicIconStickersFunc         func() (echosphere.APIResponseStickers, error)
+	SendStickerFunc                       func(string, int64, *echosphere.StickerOptions) (echosphere.APIResponseMessage, error)
+	GetStickerSetFunc                     func(string) (echosphere.APIResponseStickerSet, error)
+	GetCustomEmojiStickersFunc            func(...string) (echosphere.APIResponseStickers, error)
+	UploadStickerFileFunc                 func(int64, echosphere.InputFile, echosphere.StickerFormat) (echosphere.APIResponseFile, error)
+	CreateNewStickerSetFunc               func(int64, string, string, []echosphere.InputSticker, *echosphere.NewStickerSetOptions) (echosphere.APIResponseBool, error)
+	AddStickerToSetFunc                   func(int64, string, echosphere.InputSticker) (echosphere.APIResponseBool, error)
+	SetStickerPositionInSetFunc           func(string, int) (echosphere.APIResponseBase, error)
+	DeleteStickerFromSetFunc              func(string) (echosphere.APIResponseBase, error)
+	ReplaceStickerInSetFunc               func(int64, string, string, echosphere.InputSticker) (echosphere.APIResponseBool, error)
+	SetStickerEmojiListFunc               func(string, []string) (echosphere.APIResponseBool, error)
+	SetStickerKeywordsFunc                func(string, []string) (echosphere.APIResponseBool, error)
+	SetStickerMaskPositionFunc            func(string, echosphere.MaskPosition) (echosphere.APIResponseBool, error)
+	SetStickerSetTitleFunc                func(string, string) (echosphere.APIResponseBool, error)
+	SetStickerSetThumbnailFunc            func(string, int64, echosphere.InputFile, echosphere.StickerFormat) (echosphere.APIResponseBase, error)
+	SetCustomEmojiStickerSetThumbnailFunc func(string, string) (echosphere.APIResponseBool, error)
+	DeleteStickerSetFunc                  func(string) (echosphere.APIResponseBool, error)
+	AnswerCallbackQueryFunc               func(string, *echosphere.CallbackQueryOptions) (echosphere.APIResponseBool, error)
+	AnswerInlineQueryFunc                 func(string, []echosphere.InlineQueryResult, *echosphere.InlineQueryOptions) (echosphere.APIResponseBase, error)
+	AnswerWebAppQueryFunc                 func(string, echosphere.InlineQueryResult) (echosphere.APIResponseSentWebAppMessage, error)
+	SendInvoiceFunc                       func(int64, string, string, string, string, string, []echosphere.LabeledPrice, *echosphere.InvoiceOptions) (echosphere.APIResponseMessage, error)
+	CreateInvoiceLinkFunc                 func(string, string, string, string, string, []echosphere.LabeledPrice, *echosphere.CreateInvoiceLinkOptions) (echosphere.APIResponseBase, error)
+	AnswerShippingQueryFunc               func(string, bool, *echosphere.ShippingQueryOptions) (echosphere.APIResponseBase, error)
+	AnswerPreCheckoutQueryFunc            func(string, bool, *echosphere.PreCheckoutOptions) (echosphere.APIResponseBase, error)
+	SendGameFunc                          func(string, int64, *echosphere.BaseOptions) (echosphere.APIResponseMessage, error)
+	SetGameScoreFunc                      func(int64, int, echosphere.MessageIDOptions, *echosphere.GameScoreOptions) (echosphere.APIResponseMessage, error)
+	GetGameHighScoresFunc                 func(int64, echosphere.MessageIDOptions) (echosphere.APIResponseGameHighScore, error)
+	SetPassportDataErrorsFunc             func(int64, []echosphere.PassportElementError) (echosphere.APIResponseBool, error)
+	calls
+}
+
+// GetUpdates records the call and calls GetUpdatesFunc.
+func (m *MockAPI) GetUpdates(opts *echosphere.UpdateOptions) (r0 echosphere.APIResponseUpdate, r1 error) {
+	m.record("GetUpdates", opts)
+	if m.GetUpdatesFunc != nil {
+		return m.GetUpdatesFunc(opts)
+	}
+	return
+}
+
+// SetWebhook records the call and calls SetWebhookFunc.
+func (m *MockAPI) SetWebhook(webhookURL string, dropPendingUpdates bool, opts *echosphere.WebhookOptions) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("SetWebhook", webhookURL, dropPendingUpdates, opts)
+	if m.SetWebhookFunc != nil {
+		return m.SetWebhookFunc(webhookURL, dropPendingUpdates, opts)
+	}
+	return
+}
+
+// DeleteWebhook records the call and calls DeleteWebhookFunc.
+func (m *MockAPI) DeleteWebhook(dropPendingUpdates bool) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("DeleteWebhook", dropPendingUpdates)
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(dropPendingUpdates)
+	}
+	return
+}
+
+// GetWebhookInfo records the call and calls GetWebhookInfoFunc.
+func (m *MockAPI) GetWebhookInfo() (r0 echosphere.APIResponseWebhook, r1 error) {
+	m.record("GetWebhookInfo")
+	if m.GetWebhookInfoFunc != nil {
+		return m.GetWebhookInfoFunc()
+	}
+	return
+}
+
+// GetMe records the call and calls GetMeFunc.
+func (m *MockAPI) GetMe() (r0 echosphere.APIResponseUser, r1 error) {
+	m.record("GetMe")
+	if m.GetMeFunc != nil {
+		return m.GetMeFunc()
+	}
+	return
+}
+
+// LogOut records the call and calls LogOutFunc.
+func (m *MockAPI) LogOut() (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("LogOut")
+	if m.LogOutFunc != nil {
+		return m.LogOutFunc()
+	}
+	return
+}
+
+// Close records the call and calls CloseFunc.
+func (m *MockAPI) Close() (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("Close")
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	return
+}
+
+// SetMyCommands records the call and calls SetMyCommandsFunc.
+func (m *MockAPI) SetMyCommands(opts *echosphere.CommandOptions, commands ...echosphere.BotCommand) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetMyCommands", opts, commands)
+	if m.SetMyCommandsFunc != nil {
+		return m.SetMyCommandsFunc(opts, commands...)
+	}
+	return
+}
+
+// DeleteMyCommands records the call and calls DeleteMyCommandsFunc.
+func (m *MockAPI) DeleteMyCommands(opts *echosphere.CommandOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeleteMyCommands", opts)
+	if m.DeleteMyCommandsFunc != nil {
+		return m.DeleteMyCommandsFunc(opts)
+	}
+	return
+}
+
+// GetMyCommands records the call and calls GetMyCommandsFunc.
+func (m *MockAPI) GetMyCommands(opts *echosphere.CommandOptions) (r0 echosphere.APIResponseCommands, r1 error) {
+	m.record("GetMyCommands", opts)
+	if m.GetMyCommandsFunc != nil {
+		return m.GetMyCommandsFunc(opts)
+	}
+	return
+}
+
+// SetMyName records the call and calls SetMyNameFunc.
+func (m *MockAPI) SetMyName(name string, languageCode string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetMyName", name, languageCode)
+	if m.SetMyNameFunc != nil {
+		return m.SetMyNameFunc(name, languageCode)
+	}
+	return
+}
+
+// GetMyName records the call and calls GetMyNameFunc.
+func (m *MockAPI) GetMyName(languageCode string) (r0 echosphere.APIResponseBotName, r1 error) {
+	m.record("GetMyName", languageCode)
+	if m.GetMyNameFunc != nil {
+		return m.GetMyNameFunc(languageCode)
+	}
+	return
+}
+
+// SetMyDescription records the call and calls SetMyDescriptionFunc.
+func (m *MockAPI) SetMyDescription(description string, languageCode string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetMyDescription", description, languageCode)
+	if m.SetMyDescriptionFunc != nil {
+		return m.SetMyDescriptionFunc(description, languageCode)
+	}
+	return
+}
+
+// GetMyDescription records the call and calls GetMyDescriptionFunc.
+func (m *MockAPI) GetMyDescription(languageCode string) (r0 echosphere.APIResponseBotDescription, r1 error) {
+	m.record("GetMyDescription", languageCode)
+	if m.GetMyDescriptionFunc != nil {
+		return m.GetMyDescriptionFunc(languageCode)
+	}
+	return
+}
+
+// SetMyShortDescription records the call and calls SetMyShortDescriptionFunc.
+func (m *MockAPI) SetMyShortDescription(shortDescription string, languageCode string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetMyShortDescription", shortDescription, languageCode)
+	if m.SetMyShortDescriptionFunc != nil {
+		return m.SetMyShortDescriptionFunc(shortDescription, languageCode)
+	}
+	return
+}
+
+// GetMyShortDescription records the call and calls GetMyShortDescriptionFunc.
+func (m *MockAPI) GetMyShortDescription(languageCode string) (r0 echosphere.APIResponseBotShortDescription, r1 error) {
+	m.record("GetMyShortDescription", languageCode)
+	if m.GetMyShortDescriptionFunc != nil {
+		return m.GetMyShortDescriptionFunc(languageCode)
+	}
+	return
+}
+
+// SetMyDefaultAdministratorRights records the call and calls SetMyDefaultAdministratorRightsFunc.
+func (m *MockAPI) SetMyDefaultAdministratorRights(opts *echosphere.SetMyDefaultAdministratorRightsOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetMyDefaultAdministratorRights", opts)
+	if m.SetMyDefaultAdministratorRightsFunc != nil {
+		return m.SetMyDefaultAdministratorRightsFunc(opts)
+	}
+	return
+}
+
+// GetMyDefaultAdministratorRights records the call and calls GetMyDefaultAdministratorRightsFunc.
+func (m *MockAPI) GetMyDefaultAdministratorRights(opts *echosphere.GetMyDefaultAdministratorRightsOptions) (r0 echosphere.APIResponseChatAdministratorRights, r1 error) {
+	m.record("GetMyDefaultAdministratorRights", opts)
+	if m.GetMyDefaultAdministratorRightsFunc != nil {
+		return m.GetMyDefaultAdministratorRightsFunc(opts)
+	}
+	return
+}
+
+// SetChatMenuButton records the call and calls SetChatMenuButtonFunc.
+func (m *MockAPI) SetChatMenuButton(opts *echosphere.SetChatMenuButtonOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatMenuButton", opts)
+	if m.SetChatMenuButtonFunc != nil {
+		return m.SetChatMenuButtonFunc(opts)
+	}
+	return
+}
+
+// GetChatMenuButton records the call and calls GetChatMenuButtonFunc.
+func (m *MockAPI) GetChatMenuButton(opts *echosphere.GetChatMenuButtonOptions) (r0 echosphere.APIResponseMenuButton, r1 error) {
+	m.record("GetChatMenuButton", opts)
+	if m.GetChatMenuButtonFunc != nil {
+		return m.GetChatMenuButtonFunc(opts)
+	}
+	return
+}
+
+// GetBusinessConnection records the call and calls GetBusinessConnectionFunc.
+func (m *MockAPI) GetBusinessConnection(businessConnectionID string) (r0 echosphere.APIResponseBusinessConnection, r1 error) {
+	m.record("GetBusinessConnection", businessConnectionID)
+	if m.GetBusinessConnectionFunc != nil {
+		return m.GetBusinessConnectionFunc(businessConnectionID)
+	}
+	return
+}
+
+// SendMessage records the call and calls SendMessageFunc.
+func (m *MockAPI) SendMessage(text string, chatID int64, opts *echosphere.MessageOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendMessage", text, chatID, opts)
+	if m.SendMessageFunc != nil {
+		return m.SendMessageFunc(text, chatID, opts)
+	}
+	return
+}
+
+// SendLongMessage records the call and calls SendLongMessageFunc.
+func (m *MockAPI) SendLongMessage(text string, chatID int64, opts *echosphere.MessageOptions) (r0 []echosphere.APIResponseMessage, r1 error) {
+	m.record("SendLongMessage", text, chatID, opts)
+	if m.SendLongMessageFunc != nil {
+		return m.SendLongMessageFunc(text, chatID, opts)
+	}
+	return
+}
+
+// SendLongCaption records the call and calls SendLongCaptionFunc.
+func (m *MockAPI) SendLongCaption(chatID int64, caption string, send echosphere.CaptionFunc, opts *echosphere.MessageOptions) (r0 []echosphere.APIResponseMessage, r1 error) {
+	m.record("SendLongCaption", chatID, caption, send, opts)
+	if m.SendLongCaptionFunc != nil {
+		return m.SendLongCaptionFunc(chatID, caption, send, opts)
+	}
+	return
+}
+
+// ForwardMessage records the call and calls ForwardMessageFunc.
+func (m *MockAPI) ForwardMessage(chatID int64, fromChatID int64, messageID int, opts *echosphere.ForwardOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("ForwardMessage", chatID, fromChatID, messageID, opts)
+	if m.ForwardMessageFunc != nil {
+		return m.ForwardMessageFunc(chatID, fromChatID, messageID, opts)
+	}
+	return
+}
+
+// ForwardMessages records the call and calls ForwardMessagesFunc.
+func (m *MockAPI) ForwardMessages(chatID int64, fromChatID int64, messageIDs []int, opts *echosphere.ForwardOptions) (r0 echosphere.APIResponseMessageIDs, r1 error) {
+	m.record("ForwardMessages", chatID, fromChatID, messageIDs, opts)
+	if m.ForwardMessagesFunc != nil {
+		return m.ForwardMessagesFunc(chatID, fromChatID, messageIDs, opts)
+	}
+	return
+}
+
+// CopyMessage records the call and calls CopyMessageFunc.
+func (m *MockAPI) CopyMessage(chatID int64, fromChatID int64, messageID int, opts *echosphere.CopyOptions) (r0 echosphere.APIResponseMessageID, r1 error) {
+	m.record("CopyMessage", chatID, fromChatID, messageID, opts)
+	if m.CopyMessageFunc != nil {
+		return m.CopyMessageFunc(chatID, fromChatID, messageID, opts)
+	}
+	return
+}
+
+// CopyMessages records the call and calls CopyMessagesFunc.
+func (m *MockAPI) CopyMessages(chatID int64, fromChatID int64, messageIDs []int, opts *echosphere.CopyMessagesOptions) (r0 echosphere.APIResponseMessageIDs, r1 error) {
+	m.record("CopyMessages", chatID, fromChatID, messageIDs, opts)
+	if m.CopyMessagesFunc != nil {
+		return m.CopyMessagesFunc(chatID, fromChatID, messageIDs, opts)
+	}
+	return
+}
+
+// SendPhoto records the call and calls SendPhotoFunc.
+func (m *MockAPI) SendPhoto(file echosphere.InputFile, chatID int64, opts *echosphere.PhotoOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendPhoto", file, chatID, opts)
+	if m.SendPhotoFunc != nil {
+		return m.SendPhotoFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendAudio records the call and calls SendAudioFunc.
+func (m *MockAPI) SendAudio(file echosphere.InputFile, chatID int64, opts *echosphere.AudioOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendAudio", file, chatID, opts)
+	if m.SendAudioFunc != nil {
+		return m.SendAudioFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendDocument records the call and calls SendDocumentFunc.
+func (m *MockAPI) SendDocument(file echosphere.InputFile, chatID int64, opts *echosphere.DocumentOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendDocument", file, chatID, opts)
+	if m.SendDocumentFunc != nil {
+		return m.SendDocumentFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendVideo records the call and calls SendVideoFunc.
+func (m *MockAPI) SendVideo(file echosphere.InputFile, chatID int64, opts *echosphere.VideoOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendVideo", file, chatID, opts)
+	if m.SendVideoFunc != nil {
+		return m.SendVideoFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendAnimation records the call and calls SendAnimationFunc.
+func (m *MockAPI) SendAnimation(file echosphere.InputFile, chatID int64, opts *echosphere.AnimationOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendAnimation", file, chatID, opts)
+	if m.SendAnimationFunc != nil {
+		return m.SendAnimationFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendVoice records the call and calls SendVoiceFunc.
+func (m *MockAPI) SendVoice(file echosphere.InputFile, chatID int64, opts *echosphere.VoiceOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendVoice", file, chatID, opts)
+	if m.SendVoiceFunc != nil {
+		return m.SendVoiceFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendVideoNote records the call and calls SendVideoNoteFunc.
+func (m *MockAPI) SendVideoNote(file echosphere.InputFile, chatID int64, opts *echosphere.VideoNoteOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendVideoNote", file, chatID, opts)
+	if m.SendVideoNoteFunc != nil {
+		return m.SendVideoNoteFunc(file, chatID, opts)
+	}
+	return
+}
+
+// SendMediaGroup records the call and calls SendMediaGroupFunc.
+func (m *MockAPI) SendMediaGroup(chatID int64, media []echosphere.GroupableInputMedia, opts *echosphere.MediaGroupOptions) (r0 echosphere.APIResponseMessageArray, r1 error) {
+	m.record("SendMediaGroup", chatID, media, opts)
+	if m.SendMediaGroupFunc != nil {
+		return m.SendMediaGroupFunc(chatID, media, opts)
+	}
+	return
+}
+
+// SendAlbum records the call and calls SendAlbumFunc.
+func (m *MockAPI) SendAlbum(chatID int64, media []echosphere.GroupableInputMedia, opts *echosphere.AlbumOptions) (r0 []*echosphere.Message, r1 error) {
+	m.record("SendAlbum", chatID, media, opts)
+	if m.SendAlbumFunc != nil {
+		return m.SendAlbumFunc(chatID, media, opts)
+	}
+	return
+}
+
+// SendLocation records the call and calls SendLocationFunc.
+func (m *MockAPI) SendLocation(chatID int64, latitude float64, longitude float64, opts *echosphere.LocationOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendLocation", chatID, latitude, longitude, opts)
+	if m.SendLocationFunc != nil {
+		return m.SendLocationFunc(chatID, latitude, longitude, opts)
+	}
+	return
+}
+
+// EditMessageLiveLocation records the call and calls EditMessageLiveLocationFunc.
+func (m *MockAPI) EditMessageLiveLocation(msg echosphere.MessageIDOptions, latitude float64, longitude float64, opts *echosphere.EditLocationOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("EditMessageLiveLocation", msg, latitude, longitude, opts)
+	if m.EditMessageLiveLocationFunc != nil {
+		return m.EditMessageLiveLocationFunc(msg, latitude, longitude, opts)
+	}
+	return
+}
+
+// StopMessageLiveLocation records the call and calls StopMessageLiveLocationFunc.
+func (m *MockAPI) StopMessageLiveLocation(msg echosphere.MessageIDOptions, opts *echosphere.MessageReplyMarkup) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("StopMessageLiveLocation", msg, opts)
+	if m.StopMessageLiveLocationFunc != nil {
+		return m.StopMessageLiveLocationFunc(msg, opts)
+	}
+	return
+}
+
+// SendVenue records the call and calls SendVenueFunc.
+func (m *MockAPI) SendVenue(chatID int64, latitude float64, longitude float64, title string, address string, opts *echosphere.VenueOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendVenue", chatID, latitude, longitude, title, address, opts)
+	if m.SendVenueFunc != nil {
+		return m.SendVenueFunc(chatID, latitude, longitude, title, address, opts)
+	}
+	return
+}
+
+// SendContact records the call and calls SendContactFunc.
+func (m *MockAPI) SendContact(phoneNumber string, firstName string, chatID int64, opts *echosphere.ContactOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendContact", phoneNumber, firstName, chatID, opts)
+	if m.SendContactFunc != nil {
+		return m.SendContactFunc(phoneNumber, firstName, chatID, opts)
+	}
+	return
+}
+
+// SendPoll records the call and calls SendPollFunc.
+func (m *MockAPI) SendPoll(chatID int64, question string, options []echosphere.InputPollOption, opts *echosphere.PollOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendPoll", chatID, question, options, opts)
+	if m.SendPollFunc != nil {
+		return m.SendPollFunc(chatID, question, options, opts)
+	}
+	return
+}
+
+// SendDice records the call and calls SendDiceFunc.
+func (m *MockAPI) SendDice(chatID int64, emoji echosphere.DiceEmoji, opts *echosphere.BaseOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendDice", chatID, emoji, opts)
+	if m.SendDiceFunc != nil {
+		return m.SendDiceFunc(chatID, emoji, opts)
+	}
+	return
+}
+
+// SendChatAction records the call and calls SendChatActionFunc.
+func (m *MockAPI) SendChatAction(action echosphere.ChatAction, chatID int64, opts *echosphere.ChatActionOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SendChatAction", action, chatID, opts)
+	if m.SendChatActionFunc != nil {
+		return m.SendChatActionFunc(action, chatID, opts)
+	}
+	return
+}
+
+// SetMessageReaction records the call and calls SetMessageReactionFunc.
+func (m *MockAPI) SetMessageReaction(chatID int64, messageID int, opts *echosphere.MessageReactionOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetMessageReaction", chatID, messageID, opts)
+	if m.SetMessageReactionFunc != nil {
+		return m.SetMessageReactionFunc(chatID, messageID, opts)
+	}
+	return
+}
+
+// EditMessageText records the call and calls EditMessageTextFunc.
+func (m *MockAPI) EditMessageText(text string, msg echosphere.MessageIDOptions, opts *echosphere.MessageTextOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("EditMessageText", text, msg, opts)
+	if m.EditMessageTextFunc != nil {
+		return m.EditMessageTextFunc(text, msg, opts)
+	}
+	return
+}
+
+// EditMessageCaption records the call and calls EditMessageCaptionFunc.
+func (m *MockAPI) EditMessageCaption(msg echosphere.MessageIDOptions, opts *echosphere.MessageCaptionOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("EditMessageCaption", msg, opts)
+	if m.EditMessageCaptionFunc != nil {
+		return m.EditMessageCaptionFunc(msg, opts)
+	}
+	return
+}
+
+// EditMessageMedia records the call and calls EditMessageMediaFunc.
+func (m *MockAPI) EditMessageMedia(msg echosphere.MessageIDOptions, media echosphere.InputMedia, opts *echosphere.MessageReplyMarkup) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("EditMessageMedia", msg, media, opts)
+	if m.EditMessageMediaFunc != nil {
+		return m.EditMessageMediaFunc(msg, media, opts)
+	}
+	return
+}
+
+// EditMessageReplyMarkup records the call and calls EditMessageReplyMarkupFunc.
+func (m *MockAPI) EditMessageReplyMarkup(msg echosphere.MessageIDOptions, opts *echosphere.MessageReplyMarkup) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("EditMessageReplyMarkup", msg, opts)
+	if m.EditMessageReplyMarkupFunc != nil {
+		return m.EditMessageReplyMarkupFunc(msg, opts)
+	}
+	return
+}
+
+// StopPoll records the call and calls StopPollFunc.
+func (m *MockAPI) StopPoll(chatID int64, messageID int, opts *echosphere.MessageReplyMarkup) (r0 echosphere.APIResponsePoll, r1 error) {
+	m.record("StopPoll", chatID, messageID, opts)
+	if m.StopPollFunc != nil {
+		return m.StopPollFunc(chatID, messageID, opts)
+	}
+	return
+}
+
+// DeleteMessage records the call and calls DeleteMessageFunc.
+func (m *MockAPI) DeleteMessage(chatID int64, messageID int) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("DeleteMessage", chatID, messageID)
+	if m.DeleteMessageFunc != nil {
+		return m.DeleteMessageFunc(chatID, messageID)
+	}
+	return
+}
+
+// DeleteMessages records the call and calls DeleteMessagesFunc.
+func (m *MockAPI) DeleteMessages(chatID int64, messageIDs []int) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeleteMessages", chatID, messageIDs)
+	if m.DeleteMessagesFunc != nil {
+		return m.DeleteMessagesFunc(chatID, messageIDs)
+	}
+	return
+}
+
+// GetUserProfilePhotos records the call and calls GetUserProfilePhotosFunc.
+func (m *MockAPI) GetUserProfilePhotos(userID int64, opts *echosphere.UserProfileOptions) (r0 echosphere.APIResponseUserProfile, r1 error) {
+	m.record("GetUserProfilePhotos", userID, opts)
+	if m.GetUserProfilePhotosFunc != nil {
+		return m.GetUserProfilePhotosFunc(userID, opts)
+	}
+	return
+}
+
+// GetFile records the call and calls GetFileFunc.
+func (m *MockAPI) GetFile(fileID string) (r0 echosphere.APIResponseFile, r1 error) {
+	m.record("GetFile", fileID)
+	if m.GetFileFunc != nil {
+		return m.GetFileFunc(fileID)
+	}
+	return
+}
+
+// DownloadFile records the call and calls DownloadFileFunc.
+func (m *MockAPI) DownloadFile(filePath string) (r0 []byte, r1 error) {
+	m.record("DownloadFile", filePath)
+	if m.DownloadFileFunc != nil {
+		return m.DownloadFileFunc(filePath)
+	}
+	return
+}
+
+// DownloadFileTo records the call and calls DownloadFileToFunc.
+func (m *MockAPI) DownloadFileTo(ctx context.Context, filePath string, w io.Writer) (r0 int64, r1 error) {
+	m.record("DownloadFileTo", ctx, filePath, w)
+	if m.DownloadFileToFunc != nil {
+		return m.DownloadFileToFunc(ctx, filePath, w)
+	}
+	return
+}
+
+// ResumeDownload records the call and calls ResumeDownloadFunc.
+func (m *MockAPI) ResumeDownload(ctx context.Context, filePath string, w io.Writer, offset int64) (r0 int64, r1 error) {
+	m.record("ResumeDownload", ctx, filePath, w, offset)
+	if m.ResumeDownloadFunc != nil {
+		return m.ResumeDownloadFunc(ctx, filePath, w, offset)
+	}
+	return
+}
+
+// OpenFile records the call and calls OpenFileFunc.
+func (m *MockAPI) OpenFile(ctx context.Context, filePath string) (r0 io.ReadCloser, r1 error) {
+	m.record("OpenFile", ctx, filePath)
+	if m.OpenFileFunc != nil {
+		return m.OpenFileFunc(ctx, filePath)
+	}
+	return
+}
+
+// DownloadByFileID records the call and calls DownloadByFileIDFunc.
+func (m *MockAPI) DownloadByFileID(ctx context.Context, fileID string, w io.Writer) (r0 *echosphere.File, r1 error) {
+	m.record("DownloadByFileID", ctx, fileID, w)
+	if m.DownloadByFileIDFunc != nil {
+		return m.DownloadByFileIDFunc(ctx, fileID, w)
+	}
+	return
+}
+
+// BanChatMember records the call and calls BanChatMemberFunc.
+func (m *MockAPI) BanChatMember(chatID int64, userID int64, opts *echosphere.BanOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("BanChatMember", chatID, userID, opts)
+	if m.BanChatMemberFunc != nil {
+		return m.BanChatMemberFunc(chatID, userID, opts)
+	}
+	return
+}
+
+// UnbanChatMember records the call and calls UnbanChatMemberFunc.
+func (m *MockAPI) UnbanChatMember(chatID int64, userID int64, opts *echosphere.UnbanOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnbanChatMember", chatID, userID, opts)
+	if m.UnbanChatMemberFunc != nil {
+		return m.UnbanChatMemberFunc(chatID, userID, opts)
+	}
+	return
+}
+
+// RestrictChatMember records the call and calls RestrictChatMemberFunc.
+func (m *MockAPI) RestrictChatMember(chatID int64, userID int64, permissions echosphere.ChatPermissions, opts *echosphere.RestrictOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("RestrictChatMember", chatID, userID, permissions, opts)
+	if m.RestrictChatMemberFunc != nil {
+		return m.RestrictChatMemberFunc(chatID, userID, permissions, opts)
+	}
+	return
+}
+
+// PromoteChatMember records the call and calls PromoteChatMemberFunc.
+func (m *MockAPI) PromoteChatMember(chatID int64, userID int64, opts *echosphere.PromoteOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("PromoteChatMember", chatID, userID, opts)
+	if m.PromoteChatMemberFunc != nil {
+		return m.PromoteChatMemberFunc(chatID, userID, opts)
+	}
+	return
+}
+
+// SetChatAdministratorCustomTitle records the call and calls SetChatAdministratorCustomTitleFunc.
+func (m *MockAPI) SetChatAdministratorCustomTitle(chatID int64, userID int64, customTitle string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatAdministratorCustomTitle", chatID, userID, customTitle)
+	if m.SetChatAdministratorCustomTitleFunc != nil {
+		return m.SetChatAdministratorCustomTitleFunc(chatID, userID, customTitle)
+	}
+	return
+}
+
+// BanChatSenderChat records the call and calls BanChatSenderChatFunc.
+func (m *MockAPI) BanChatSenderChat(chatID int64, senderChatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("BanChatSenderChat", chatID, senderChatID)
+	if m.BanChatSenderChatFunc != nil {
+		return m.BanChatSenderChatFunc(chatID, senderChatID)
+	}
+	return
+}
+
+// UnbanChatSenderChat records the call and calls UnbanChatSenderChatFunc.
+func (m *MockAPI) UnbanChatSenderChat(chatID int64, senderChatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnbanChatSenderChat", chatID, senderChatID)
+	if m.UnbanChatSenderChatFunc != nil {
+		return m.UnbanChatSenderChatFunc(chatID, senderChatID)
+	}
+	return
+}
+
+// SetChatPermissions records the call and calls SetChatPermissionsFunc.
+func (m *MockAPI) SetChatPermissions(chatID int64, permissions echosphere.ChatPermissions, opts *echosphere.ChatPermissionsOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatPermissions", chatID, permissions, opts)
+	if m.SetChatPermissionsFunc != nil {
+		return m.SetChatPermissionsFunc(chatID, permissions, opts)
+	}
+	return
+}
+
+// ExportChatInviteLink records the call and calls ExportChatInviteLinkFunc.
+func (m *MockAPI) ExportChatInviteLink(chatID int64) (r0 echosphere.APIResponseString, r1 error) {
+	m.record("ExportChatInviteLink", chatID)
+	if m.ExportChatInviteLinkFunc != nil {
+		return m.ExportChatInviteLinkFunc(chatID)
+	}
+	return
+}
+
+// CreateChatInviteLink records the call and calls CreateChatInviteLinkFunc.
+func (m *MockAPI) CreateChatInviteLink(chatID int64, opts *echosphere.InviteLinkOptions) (r0 echosphere.APIResponseInviteLink, r1 error) {
+	m.record("CreateChatInviteLink", chatID, opts)
+	if m.CreateChatInviteLinkFunc != nil {
+		return m.CreateChatInviteLinkFunc(chatID, opts)
+	}
+	return
+}
+
+// EditChatInviteLink records the call and calls EditChatInviteLinkFunc.
+func (m *MockAPI) EditChatInviteLink(chatID int64, inviteLink string, opts *echosphere.InviteLinkOptions) (r0 echosphere.APIResponseInviteLink, r1 error) {
+	m.record("EditChatInviteLink", chatID, inviteLink, opts)
+	if m.EditChatInviteLinkFunc != nil {
+		return m.EditChatInviteLinkFunc(chatID, inviteLink, opts)
+	}
+	return
+}
+
+// RevokeChatInviteLink records the call and calls RevokeChatInviteLinkFunc.
+func (m *MockAPI) RevokeChatInviteLink(chatID int64, inviteLink string) (r0 echosphere.APIResponseInviteLink, r1 error) {
+	m.record("RevokeChatInviteLink", chatID, inviteLink)
+	if m.RevokeChatInviteLinkFunc != nil {
+		return m.RevokeChatInviteLinkFunc(chatID, inviteLink)
+	}
+	return
+}
+
+// ApproveChatJoinRequest records the call and calls ApproveChatJoinRequestFunc.
+func (m *MockAPI) ApproveChatJoinRequest(chatID int64, userID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("ApproveChatJoinRequest", chatID, userID)
+	if m.ApproveChatJoinRequestFunc != nil {
+		return m.ApproveChatJoinRequestFunc(chatID, userID)
+	}
+	return
+}
+
+// DeclineChatJoinRequest records the call and calls DeclineChatJoinRequestFunc.
+func (m *MockAPI) DeclineChatJoinRequest(chatID int64, userID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeclineChatJoinRequest", chatID, userID)
+	if m.DeclineChatJoinRequestFunc != nil {
+		return m.DeclineChatJoinRequestFunc(chatID, userID)
+	}
+	return
+}
+
+// SetChatPhoto records the call and calls SetChatPhotoFunc.
+func (m *MockAPI) SetChatPhoto(file echosphere.InputFile, chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatPhoto", file, chatID)
+	if m.SetChatPhotoFunc != nil {
+		return m.SetChatPhotoFunc(file, chatID)
+	}
+	return
+}
+
+// DeleteChatPhoto records the call and calls DeleteChatPhotoFunc.
+func (m *MockAPI) DeleteChatPhoto(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeleteChatPhoto", chatID)
+	if m.DeleteChatPhotoFunc != nil {
+		return m.DeleteChatPhotoFunc(chatID)
+	}
+	return
+}
+
+// SetChatTitle records the call and calls SetChatTitleFunc.
+func (m *MockAPI) SetChatTitle(chatID int64, title string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatTitle", chatID, title)
+	if m.SetChatTitleFunc != nil {
+		return m.SetChatTitleFunc(chatID, title)
+	}
+	return
+}
+
+// SetChatDescription records the call and calls SetChatDescriptionFunc.
+func (m *MockAPI) SetChatDescription(chatID int64, description string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatDescription", chatID, description)
+	if m.SetChatDescriptionFunc != nil {
+		return m.SetChatDescriptionFunc(chatID, description)
+	}
+	return
+}
+
+// PinChatMessage records the call and calls PinChatMessageFunc.
+func (m *MockAPI) PinChatMessage(chatID int64, messageID int, opts *echosphere.PinMessageOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("PinChatMessage", chatID, messageID, opts)
+	if m.PinChatMessageFunc != nil {
+		return m.PinChatMessageFunc(chatID, messageID, opts)
+	}
+	return
+}
+
+// UnpinChatMessage records the call and calls UnpinChatMessageFunc.
+func (m *MockAPI) UnpinChatMessage(chatID int64, messageID int) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnpinChatMessage", chatID, messageID)
+	if m.UnpinChatMessageFunc != nil {
+		return m.UnpinChatMessageFunc(chatID, messageID)
+	}
+	return
+}
+
+// UnpinAllChatMessages records the call and calls UnpinAllChatMessagesFunc.
+func (m *MockAPI) UnpinAllChatMessages(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnpinAllChatMessages", chatID)
+	if m.UnpinAllChatMessagesFunc != nil {
+		return m.UnpinAllChatMessagesFunc(chatID)
+	}
+	return
+}
+
+// LeaveChat records the call and calls LeaveChatFunc.
+func (m *MockAPI) LeaveChat(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("LeaveChat", chatID)
+	if m.LeaveChatFunc != nil {
+		return m.LeaveChatFunc(chatID)
+	}
+	return
+}
+
+// GetChat records the call and calls GetChatFunc.
+func (m *MockAPI) GetChat(chatID int64) (r0 echosphere.APIResponseChat, r1 error) {
+	m.record("GetChat", chatID)
+	if m.GetChatFunc != nil {
+		return m.GetChatFunc(chatID)
+	}
+	return
+}
+
+// GetChatAdministrators records the call and calls GetChatAdministratorsFunc.
+func (m *MockAPI) GetChatAdministrators(chatID int64) (r0 echosphere.APIResponseAdministrators, r1 error) {
+	m.record("GetChatAdministrators", chatID)
+	if m.GetChatAdministratorsFunc != nil {
+		return m.GetChatAdministratorsFunc(chatID)
+	}
+	return
+}
+
+// GetChatMemberCount records the call and calls GetChatMemberCountFunc.
+func (m *MockAPI) GetChatMemberCount(chatID int64) (r0 echosphere.APIResponseInteger, r1 error) {
+	m.record("GetChatMemberCount", chatID)
+	if m.GetChatMemberCountFunc != nil {
+		return m.GetChatMemberCountFunc(chatID)
+	}
+	return
+}
+
+// GetChatMember records the call and calls GetChatMemberFunc.
+func (m *MockAPI) GetChatMember(chatID int64, userID int64) (r0 echosphere.APIResponseChatMember, r1 error) {
+	m.record("GetChatMember", chatID, userID)
+	if m.GetChatMemberFunc != nil {
+		return m.GetChatMemberFunc(chatID, userID)
+	}
+	return
+}
+
+// SetChatStickerSet records the call and calls SetChatStickerSetFunc.
+func (m *MockAPI) SetChatStickerSet(chatID int64, stickerSetName string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetChatStickerSet", chatID, stickerSetName)
+	if m.SetChatStickerSetFunc != nil {
+		return m.SetChatStickerSetFunc(chatID, stickerSetName)
+	}
+	return
+}
+
+// DeleteChatStickerSet records the call and calls DeleteChatStickerSetFunc.
+func (m *MockAPI) DeleteChatStickerSet(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeleteChatStickerSet", chatID)
+	if m.DeleteChatStickerSetFunc != nil {
+		return m.DeleteChatStickerSetFunc(chatID)
+	}
+	return
+}
+
+// GetUserChatBoosts records the call and calls GetUserChatBoostsFunc.
+func (m *MockAPI) GetUserChatBoosts(chatID int64, userID int64) (r0 echosphere.APIResponseUserChatBoosts, r1 error) {
+	m.record("GetUserChatBoosts", chatID, userID)
+	if m.GetUserChatBoostsFunc != nil {
+		return m.GetUserChatBoostsFunc(chatID, userID)
+	}
+	return
+}
+
+// CreateForumTopic records the call and calls CreateForumTopicFunc.
+func (m *MockAPI) CreateForumTopic(chatID int64, name string, opts *echosphere.CreateTopicOptions) (r0 echosphere.APIResponseForumTopic, r1 error) {
+	m.record("CreateForumTopic", chatID, name, opts)
+	if m.CreateForumTopicFunc != nil {
+		return m.CreateForumTopicFunc(chatID, name, opts)
+	}
+	return
+}
+
+// EditForumTopic records the call and calls EditForumTopicFunc.
+func (m *MockAPI) EditForumTopic(chatID int64, messageThreadID int64, opts *echosphere.EditTopicOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("EditForumTopic", chatID, messageThreadID, opts)
+	if m.EditForumTopicFunc != nil {
+		return m.EditForumTopicFunc(chatID, messageThreadID, opts)
+	}
+	return
+}
+
+// CloseForumTopic records the call and calls CloseForumTopicFunc.
+func (m *MockAPI) CloseForumTopic(chatID int64, messageThreadID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("CloseForumTopic", chatID, messageThreadID)
+	if m.CloseForumTopicFunc != nil {
+		return m.CloseForumTopicFunc(chatID, messageThreadID)
+	}
+	return
+}
+
+// ReopenForumTopic records the call and calls ReopenForumTopicFunc.
+func (m *MockAPI) ReopenForumTopic(chatID int64, messageThreadID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("ReopenForumTopic", chatID, messageThreadID)
+	if m.ReopenForumTopicFunc != nil {
+		return m.ReopenForumTopicFunc(chatID, messageThreadID)
+	}
+	return
+}
+
+// DeleteForumTopic records the call and calls DeleteForumTopicFunc.
+func (m *MockAPI) DeleteForumTopic(chatID int64, messageThreadID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeleteForumTopic", chatID, messageThreadID)
+	if m.DeleteForumTopicFunc != nil {
+		return m.DeleteForumTopicFunc(chatID, messageThreadID)
+	}
+	return
+}
+
+// UnpinAllForumTopicMessages records the call and calls UnpinAllForumTopicMessagesFunc.
+func (m *MockAPI) UnpinAllForumTopicMessages(chatID int64, messageThreadID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnpinAllForumTopicMessages", chatID, messageThreadID)
+	if m.UnpinAllForumTopicMessagesFunc != nil {
+		return m.UnpinAllForumTopicMessagesFunc(chatID, messageThreadID)
+	}
+	return
+}
+
+// EditGeneralForumTopic records the call and calls EditGeneralForumTopicFunc.
+func (m *MockAPI) EditGeneralForumTopic(chatID int64, name string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("EditGeneralForumTopic", chatID, name)
+	if m.EditGeneralForumTopicFunc != nil {
+		return m.EditGeneralForumTopicFunc(chatID, name)
+	}
+	return
+}
+
+// CloseGeneralForumTopic records the call and calls CloseGeneralForumTopicFunc.
+func (m *MockAPI) CloseGeneralForumTopic(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("CloseGeneralForumTopic", chatID)
+	if m.CloseGeneralForumTopicFunc != nil {
+		return m.CloseGeneralForumTopicFunc(chatID)
+	}
+	return
+}
+
+// ReopenGeneralForumTopic records the call and calls ReopenGeneralForumTopicFunc.
+func (m *MockAPI) ReopenGeneralForumTopic(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("ReopenGeneralForumTopic", chatID)
+	if m.ReopenGeneralForumTopicFunc != nil {
+		return m.ReopenGeneralForumTopicFunc(chatID)
+	}
+	return
+}
+
+// HideGeneralForumTopic records the call and calls HideGeneralForumTopicFunc.
+func (m *MockAPI) HideGeneralForumTopic(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("HideGeneralForumTopic", chatID)
+	if m.HideGeneralForumTopicFunc != nil {
+		return m.HideGeneralForumTopicFunc(chatID)
+	}
+	return
+}
+
+// UnhideGeneralForumTopic records the call and calls UnhideGeneralForumTopicFunc.
+func (m *MockAPI) UnhideGeneralForumTopic(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnhideGeneralForumTopic", chatID)
+	if m.UnhideGeneralForumTopicFunc != nil {
+		return m.UnhideGeneralForumTopicFunc(chatID)
+	}
+	return
+}
+
+// UnpinAllGeneralForumTopicMessages records the call and calls UnpinAllGeneralForumTopicMessagesFunc.
+func (m *MockAPI) UnpinAllGeneralForumTopicMessages(chatID int64) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("UnpinAllGeneralForumTopicMessages", chatID)
+	if m.UnpinAllGeneralForumTopicMessagesFunc != nil {
+		return m.UnpinAllGeneralForumTopicMessagesFunc(chatID)
+	}
+	return
+}
+
+// GetForumTopicIconStickers records the call and calls GetForumTopicIconStickersFunc.
+func (m *MockAPI) GetForumTopicIconStickers() (r0 echosphere.APIResponseStickers, r1 error) {
+	m.record("GetForumTopicIconStickers")
+	if m.GetForumTopicIconStickersFunc != nil {
+		return m.GetForumTopicIconStickersFunc()
+	}
+	return
+}
+
+// SendSticker records the call and calls SendStickerFunc.
+func (m *MockAPI) SendSticker(stickerID string, chatID int64, opts *echosphere.StickerOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendSticker", stickerID, chatID, opts)
+	if m.SendStickerFunc != nil {
+		return m.SendStickerFunc(stickerID, chatID, opts)
+	}
+	return
+}
+
+// GetStickerSet records the call and calls GetStickerSetFunc.
+func (m *MockAPI) GetStickerSet(name string) (r0 echosphere.APIResponseStickerSet, r1 error) {
+	m.record("GetStickerSet", name)
+	if m.GetStickerSetFunc != nil {
+		return m.GetStickerSetFunc(name)
+	}
+	return
+}
+
+// GetCustomEmojiStickers records the call and calls GetCustomEmojiStickersFunc.
+func (m *MockAPI) GetCustomEmojiStickers(customEmojiIDs ...string) (r0 echosphere.APIResponseStickers, r1 error) {
+	m.record("GetCustomEmojiStickers", customEmojiIDs)
+	if m.GetCustomEmojiStickersFunc != nil {
+		return m.GetCustomEmojiStickersFunc(customEmojiIDs...)
+	}
+	return
+}
+
+// UploadStickerFile records the call and calls UploadStickerFileFunc.
+func (m *MockAPI) UploadStickerFile(userID int64, sticker echosphere.InputFile, format echosphere.StickerFormat) (r0 echosphere.APIResponseFile, r1 error) {
+	m.record("UploadStickerFile", userID, sticker, format)
+	if m.UploadStickerFileFunc != nil {
+		return m.UploadStickerFileFunc(userID, sticker, format)
+	}
+	return
+}
+
+// CreateNewStickerSet records the call and calls CreateNewStickerSetFunc.
+func (m *MockAPI) CreateNewStickerSet(userID int64, name string, title string, stickers []echosphere.InputSticker, opts *echosphere.NewStickerSetOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("CreateNewStickerSet", userID, name, title, stickers, opts)
+	if m.CreateNewStickerSetFunc != nil {
+		return m.CreateNewStickerSetFunc(userID, name, title, stickers, opts)
+	}
+	return
+}
+
+// AddStickerToSet records the call and calls AddStickerToSetFunc.
+func (m *MockAPI) AddStickerToSet(userID int64, name string, sticker echosphere.InputSticker) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("AddStickerToSet", userID, name, sticker)
+	if m.AddStickerToSetFunc != nil {
+		return m.AddStickerToSetFunc(userID, name, sticker)
+	}
+	return
+}
+
+// SetStickerPositionInSet records the call and calls SetStickerPositionInSetFunc.
+func (m *MockAPI) SetStickerPositionInSet(sticker string, position int) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("SetStickerPositionInSet", sticker, position)
+	if m.SetStickerPositionInSetFunc != nil {
+		return m.SetStickerPositionInSetFunc(sticker, position)
+	}
+	return
+}
+
+// DeleteStickerFromSet records the call and calls DeleteStickerFromSetFunc.
+func (m *MockAPI) DeleteStickerFromSet(sticker string) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("DeleteStickerFromSet", sticker)
+	if m.DeleteStickerFromSetFunc != nil {
+		return m.DeleteStickerFromSetFunc(sticker)
+	}
+	return
+}
+
+// ReplaceStickerInSet records the call and calls ReplaceStickerInSetFunc.
+func (m *MockAPI) ReplaceStickerInSet(userID int64, name string, oldSticker string, sticker echosphere.InputSticker) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("ReplaceStickerInSet", userID, name, oldSticker, sticker)
+	if m.ReplaceStickerInSetFunc != nil {
+		return m.ReplaceStickerInSetFunc(userID, name, oldSticker, sticker)
+	}
+	return
+}
+
+// SetStickerEmojiList records the call and calls SetStickerEmojiListFunc.
+func (m *MockAPI) SetStickerEmojiList(sticker string, emojis []string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetStickerEmojiList", sticker, emojis)
+	if m.SetStickerEmojiListFunc != nil {
+		return m.SetStickerEmojiListFunc(sticker, emojis)
+	}
+	return
+}
+
+// SetStickerKeywords records the call and calls SetStickerKeywordsFunc.
+func (m *MockAPI) SetStickerKeywords(sticker string, keywords []string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetStickerKeywords", sticker, keywords)
+	if m.SetStickerKeywordsFunc != nil {
+		return m.SetStickerKeywordsFunc(sticker, keywords)
+	}
+	return
+}
+
+// SetStickerMaskPosition records the call and calls SetStickerMaskPositionFunc.
+func (m *MockAPI) SetStickerMaskPosition(sticker string, mask echosphere.MaskPosition) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetStickerMaskPosition", sticker, mask)
+	if m.SetStickerMaskPositionFunc != nil {
+		return m.SetStickerMaskPositionFunc(sticker, mask)
+	}
+	return
+}
+
+// SetStickerSetTitle records the call and calls SetStickerSetTitleFunc.
+func (m *MockAPI) SetStickerSetTitle(name string, title string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetStickerSetTitle", name, title)
+	if m.SetStickerSetTitleFunc != nil {
+		return m.SetStickerSetTitleFunc(name, title)
+	}
+	return
+}
+
+// SetStickerSetThumbnail records the call and calls SetStickerSetThumbnailFunc.
+func (m *MockAPI) SetStickerSetThumbnail(name string, userID int64, thumbnail echosphere.InputFile, format echosphere.StickerFormat) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("SetStickerSetThumbnail", name, userID, thumbnail, format)
+	if m.SetStickerSetThumbnailFunc != nil {
+		return m.SetStickerSetThumbnailFunc(name, userID, thumbnail, format)
+	}
+	return
+}
+
+// SetCustomEmojiStickerSetThumbnail records the call and calls SetCustomEmojiStickerSetThumbnailFunc.
+func (m *MockAPI) SetCustomEmojiStickerSetThumbnail(name string, emojiID string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetCustomEmojiStickerSetThumbnail", name, emojiID)
+	if m.SetCustomEmojiStickerSetThumbnailFunc != nil {
+		return m.SetCustomEmojiStickerSetThumbnailFunc(name, emojiID)
+	}
+	return
+}
+
+// DeleteStickerSet records the call and calls DeleteStickerSetFunc.
+func (m *MockAPI) DeleteStickerSet(name string) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("DeleteStickerSet", name)
+	if m.DeleteStickerSetFunc != nil {
+		return m.DeleteStickerSetFunc(name)
+	}
+	return
+}
+
+// AnswerCallbackQuery records the call and calls AnswerCallbackQueryFunc.
+func (m *MockAPI) AnswerCallbackQuery(callbackID string, opts *echosphere.CallbackQueryOptions) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("AnswerCallbackQuery", callbackID, opts)
+	if m.AnswerCallbackQueryFunc != nil {
+		return m.AnswerCallbackQueryFunc(callbackID, opts)
+	}
+	return
+}
+
+// AnswerInlineQuery records the call and calls AnswerInlineQueryFunc.
+func (m *MockAPI) AnswerInlineQuery(inlineQueryID string, results []echosphere.InlineQueryResult, opts *echosphere.InlineQueryOptions) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("AnswerInlineQuery", inlineQueryID, results, opts)
+	if m.AnswerInlineQueryFunc != nil {
+		return m.AnswerInlineQueryFunc(inlineQueryID, results, opts)
+	}
+	return
+}
+
+// AnswerWebAppQuery records the call and calls AnswerWebAppQueryFunc.
+func (m *MockAPI) AnswerWebAppQuery(webAppQueryID string, result echosphere.InlineQueryResult) (r0 echosphere.APIResponseSentWebAppMessage, r1 error) {
+	m.record("AnswerWebAppQuery", webAppQueryID, result)
+	if m.AnswerWebAppQueryFunc != nil {
+		return m.AnswerWebAppQueryFunc(webAppQueryID, result)
+	}
+	return
+}
+
+// SendInvoice records the call and calls SendInvoiceFunc.
+func (m *MockAPI) SendInvoice(chatID int64, title string, description string, payload string, providerToken string, currency string, prices []echosphere.LabeledPrice, opts *echosphere.InvoiceOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendInvoice", chatID, title, description, payload, providerToken, currency, prices, opts)
+	if m.SendInvoiceFunc != nil {
+		return m.SendInvoiceFunc(chatID, title, description, payload, providerToken, currency, prices, opts)
+	}
+	return
+}
+
+// CreateInvoiceLink records the call and calls CreateInvoiceLinkFunc.
+func (m *MockAPI) CreateInvoiceLink(title string, description string, payload string, providerToken string, currency string, prices []echosphere.LabeledPrice, opts *echosphere.CreateInvoiceLinkOptions) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("CreateInvoiceLink", title, description, payload, providerToken, currency, prices, opts)
+	if m.CreateInvoiceLinkFunc != nil {
+		return m.CreateInvoiceLinkFunc(title, description, payload, providerToken, currency, prices, opts)
+	}
+	return
+}
+
+// AnswerShippingQuery records the call and calls AnswerShippingQueryFunc.
+func (m *MockAPI) AnswerShippingQuery(shippingQueryID string, ok bool, opts *echosphere.ShippingQueryOptions) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("AnswerShippingQuery", shippingQueryID, ok, opts)
+	if m.AnswerShippingQueryFunc != nil {
+		return m.AnswerShippingQueryFunc(shippingQueryID, ok, opts)
+	}
+	return
+}
+
+// AnswerPreCheckoutQuery records the call and calls AnswerPreCheckoutQueryFunc.
+func (m *MockAPI) AnswerPreCheckoutQuery(preCheckoutQueryID string, ok bool, opts *echosphere.PreCheckoutOptions) (r0 echosphere.APIResponseBase, r1 error) {
+	m.record("AnswerPreCheckoutQuery", preCheckoutQueryID, ok, opts)
+	if m.AnswerPreCheckoutQueryFunc != nil {
+		return m.AnswerPreCheckoutQueryFunc(preCheckoutQueryID, ok, opts)
+	}
+	return
+}
+
+// SendGame records the call and calls SendGameFunc.
+func (m *MockAPI) SendGame(gameShortName string, chatID int64, opts *echosphere.BaseOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SendGame", gameShortName, chatID, opts)
+	if m.SendGameFunc != nil {
+		return m.SendGameFunc(gameShortName, chatID, opts)
+	}
+	return
+}
+
+// SetGameScore records the call and calls SetGameScoreFunc.
+func (m *MockAPI) SetGameScore(userID int64, score int, msgID echosphere.MessageIDOptions, opts *echosphere.GameScoreOptions) (r0 echosphere.APIResponseMessage, r1 error) {
+	m.record("SetGameScore", userID, score, msgID, opts)
+	if m.SetGameScoreFunc != nil {
+		return m.SetGameScoreFunc(userID, score, msgID, opts)
+	}
+	return
+}
+
+// GetGameHighScores records the call and calls GetGameHighScoresFunc.
+func (m *MockAPI) GetGameHighScores(userID int64, opts echosphere.MessageIDOptions) (r0 echosphere.APIResponseGameHighScore, r1 error) {
+	m.record("GetGameHighScores", userID, opts)
+	if m.GetGameHighScoresFunc != nil {
+		return m.GetGameHighScoresFunc(userID, opts)
+	}
+	return
+}
+
+// SetPassportDataErrors records the call and calls SetPassportDataErrorsFunc.
+func (m *MockAPI) SetPassportDataErrors(userID int64, errors []echosphere.PassportElementError) (r0 echosphere.APIResponseBool, r1 error) {
+	m.record("SetPassportDataErrors", userID, errors)
+	if m.SetPassportDataErrorsFunc != nil {
+		return m.SetPassportDataErrorsFunc(userID, errors)
+	}
+	return
+}
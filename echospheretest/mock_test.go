@@ -0,0 +1,54 @@
+package echospheretest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+// greeter is a component depending on a subset of the Bot API.
+type greeter struct {
+	api echosphere.MessagingAPI
+}
+
+func (g greeter) greet(chatID int64, name string) error {
+	_, err := g.api.SendMessage("Hello "+name, chatID, nil)
+	return err
+}
+
+func TestMockAPI(t *testing.T) {
+	mock := &MockAPI{}
+	g := greeter{api: mock}
+
+	if err := g.greet(300, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := mock.CallsTo("SendMessage")
+	if len(calls) != 1 || calls[0].Args[0] != "Hello Alice" || calls[0].Args[1] != int64(300) {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+
+	errBlocked := errors.New("blocked")
+	mock.SendMessageFunc = func(text string, chatID int64, opts *echosphere.MessageOptions) (echosphere.APIResponseMessage, error) {
+		return echosphere.APIResponseMessage{}, errBlocked
+	}
+
+	if err := g.greet(300, "Bob"); !errors.Is(err, errBlocked) {
+		t.Fatalf("expected the error of SendMessageFunc, got %v", err)
+	}
+
+	if _, err := mock.SetMyCommands(nil, echosphere.BotCommand{Command: "start"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(mock.Calls()); n != 3 {
+		t.Fatalf("expected 3 calls, got %d", n)
+	}
+
+	mock.Reset()
+	if n := len(mock.Calls()); n != 0 {
+		t.Fatalf("expected no calls after Reset, got %d", n)
+	}
+}
@@ -0,0 +1,357 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echospheretest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RedactedToken replaces the bot token in the recorded interactions.
+const RedactedToken = "<TOKEN>"
+
+// Interaction is a request to the Bot API along with its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the canonical form of a request, which doesn't depend
+// on the order of its parameters nor on the multipart boundaries.
+type RecordedRequest struct {
+	Query  url.Values              `json:"query,omitempty"`
+	Form   url.Values              `json:"form,omitempty"`
+	Files  map[string]RecordedFile `json:"files,omitempty"`
+	Method string                  `json:"method"`
+	Path   string                  `json:"path"`
+}
+
+// RecordedFile is a file uploaded in a multipart request, identified by the hash of its content.
+type RecordedFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// RecordedResponse is the response to a request.
+// JSON bodies are stored as they are, any other body is stored in Body.
+type RecordedResponse struct {
+	ContentType string          `json:"content_type,omitempty"`
+	JSON        json.RawMessage `json:"json,omitempty"`
+	Body        []byte          `json:"body,omitempty"`
+	Status      int             `json:"status"`
+}
+
+// key returns the string used to match the request.
+func (r RecordedRequest) key() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// Recorder is an http.RoundTripper which records the requests to the Bot API and their responses,
+// so that they can be saved to a golden file and served back by a Replayer.
+// Use it with echosphere.SetHTTPClient.
+type Recorder struct {
+	next         http.RoundTripper
+	token        string
+	interactions []Interaction
+	mu           sync.Mutex
+}
+
+// NewRecorder returns a new Recorder which sends the requests through next,
+// or http.DefaultTransport if nil, redacting token from the recorded interactions.
+func NewRecorder(token string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, token: token}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := canonicalRequest(req, body, r.token)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	resp := RecordedResponse{
+		Status:      res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if redacted := redact(resBody, r.token); json.Valid(redacted) {
+		resp.JSON = redacted
+	} else {
+		resp.Body = resBody
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: rec, Response: resp})
+	r.mu.Unlock()
+	return res, nil
+}
+
+// Interactions returns the interactions recorded so far, in order.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the interactions recorded so far to the golden file at path.
+func (r *Recorder) Save(path string) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(r.Interactions()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Replayer is an http.RoundTripper which serves the interactions recorded in a golden file.
+// The requests are matched by their content regardless of the order in which they're made,
+// identical requests are served in the recorded order.
+// Unexpected requests fail with an error.
+type Replayer struct {
+	pending map[string][]RecordedResponse
+	token   string
+	mu      sync.Mutex
+}
+
+// NewReplayer returns a Replayer serving the interactions in the golden file at path.
+// The token is the one used by the bot in the test, which is redacted before matching the requests.
+func NewReplayer(path, token string) (*Replayer, error) {
+	var interactions []Interaction
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &interactions); err != nil {
+		return nil, fmt.Errorf("can't parse golden file %s: %w", path, err)
+	}
+
+	r := &Replayer{pending: make(map[string][]RecordedResponse), token: token}
+	for _, i := range interactions {
+		k := i.Request.key()
+		r.pending[k] = append(r.pending[k], i.Response)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := canonicalRequest(req, body, r.token)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	k := rec.key()
+	queue := r.pending[k]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("unexpected request %s %s", rec.Method, rec.Path)
+	}
+	resp := queue[0]
+	r.pending[k] = queue[1:]
+	r.mu.Unlock()
+
+	data := resp.Body
+	if resp.JSON != nil {
+		data = bytes.ReplaceAll(resp.JSON, []byte(RedactedToken), []byte(r.token))
+	}
+
+	header := make(http.Header)
+	if resp.ContentType != "" {
+		header.Set("Content-Type", resp.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of recorded interactions which haven't been replayed yet.
+func (r *Replayer) Remaining() (n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, q := range r.pending {
+		n += len(q)
+	}
+	return
+}
+
+// readBody reads the body of req and replaces it with a copy, so that it can still be sent.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return body, nil
+}
+
+// canonicalRequest returns the canonical form of req, whose body is body, with token redacted.
+func canonicalRequest(req *http.Request, body []byte, token string) (RecordedRequest, error) {
+	rec := RecordedRequest{
+		Method: req.Method,
+		Path:   redactString(req.URL.Path, token),
+		Query:  redactValues(req.URL.Query(), token),
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		form, files, err := parseMultipart(body, params["boundary"])
+		if err != nil {
+			return rec, err
+		}
+		rec.Form, rec.Files = redactValues(form, token), files
+
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return rec, err
+		}
+		rec.Form = redactValues(form, token)
+	}
+
+	return rec, nil
+}
+
+// parseMultipart returns the fields and the files in a multipart body.
+func parseMultipart(body []byte, boundary string) (url.Values, map[string]RecordedFile, error) {
+	var (
+		form  = make(url.Values)
+		files = make(map[string]RecordedFile)
+		mr    = multipart.NewReader(bytes.NewReader(body), boundary)
+	)
+
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if p.FileName() == "" {
+			form.Add(p.FormName(), string(data))
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		files[p.FormName()] = RecordedFile{Name: p.FileName(), SHA256: hex.EncodeToString(sum[:])}
+	}
+
+	if len(form) == 0 {
+		form = nil
+	}
+	if len(files) == 0 {
+		files = nil
+	}
+	return form, files, nil
+}
+
+func redact(b []byte, token string) []byte {
+	if token == "" {
+		return b
+	}
+	return bytes.ReplaceAll(b, []byte(token), []byte(RedactedToken))
+}
+
+func redactString(s, token string) string {
+	if token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, token, RedactedToken)
+}
+
+func redactValues(vals url.Values, token string) url.Values {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	ret := make(url.Values, len(vals))
+	for k, v := range vals {
+		for _, s := range v {
+			ret.Add(k, redactString(s, token))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,88 @@
+package echospheretest
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+func useTransport(t *testing.T, rt http.RoundTripper) {
+	echosphere.SetHTTPClient(&http.Client{Transport: rt})
+	t.Cleanup(func() { echosphere.SetHTTPClient(new(http.Client)) })
+}
+
+func session(api echosphere.API, reverse bool) error {
+	steps := []func() error{
+		func() error {
+			_, err := api.SendMessage("hello", 200, &echosphere.MessageOptions{ParseMode: echosphere.HTML})
+			return err
+		},
+		func() error {
+			_, err := api.SendDocument(echosphere.NewInputFileBytes("file.txt", []byte("content")), 200, nil)
+			return err
+		},
+		func() error {
+			_, err := api.GetMe()
+			return err
+		},
+	}
+
+	for i := range steps {
+		if reverse {
+			i = len(steps) - 1 - i
+		}
+		if err := steps[i](); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	disableChatLimit(t)
+	golden := filepath.Join(t.TempDir(), "session.json")
+
+	srv := NewServer()
+	rec := NewRecorder(token, nil)
+	useTransport(t, rec)
+
+	if err := session(echosphere.NewLocalAPI(srv.URL, token), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(golden); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) || !strings.Contains(string(data), RedactedToken) {
+		t.Fatalf("the token isn't redacted in the golden file:\n%s", data)
+	}
+
+	rep, err := NewReplayer(golden, "456:other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useTransport(t, rep)
+
+	// The server is closed, so the responses can only come from the golden file.
+	api := echosphere.NewLocalAPI(srv.URL, "456:other")
+	if err := session(api, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := rep.Remaining(); n != 0 {
+		t.Fatalf("expected all the interactions to be replayed, %d left", n)
+	}
+
+	if _, err := api.SendMessage("unexpected", 200, nil); err == nil {
+		t.Fatal("expected an error for an unexpected request")
+	}
+}
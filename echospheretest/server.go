@@ -0,0 +1,441 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package echospheretest provides utilities to test the bots built with echosphere
+// without reaching the Telegram servers.
+//
+// The Server type is an in-process fake of the Bot API which keeps chats, messages
+// and files in memory, records every request and lets the tests inject updates:
+//
+//	srv := echospheretest.NewServer()
+//	defer srv.Close()
+//
+//	api := echosphere.NewLocalAPI(srv.URL, "123:token")
+//
+// The requests still go through the rate limiters of echosphere, which can be
+// disabled with echosphere.SetChatRequestLimit(0) to speed up the tests.
+//
+// Alternatively, the Recorder captures a session with the real Bot API into a golden file,
+// which the Replayer serves back deterministically, both plugged in with echosphere.SetHTTPClient.
+//
+// The code depending on the echosphere.BotAPI interface can be tested with MockAPI,
+// which records the calls without making any request.
+//
+// The Harness drives the bots created by a NewBotFn with the updates built by
+// NewTextUpdate, NewCallbackUpdate and NewPhotoUpdate, waiting for them to be handled.
+package echospheretest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+// Request is a call to a Bot API method received by the Server.
+type Request struct {
+	Files  map[string]UploadedFile
+	Params url.Values
+	Method string
+	Token  string
+}
+
+// UploadedFile is a file uploaded in a multipart request.
+type UploadedFile struct {
+	Name string
+	Data []byte
+}
+
+// Error is the error returned by a Handler to make a method fail
+// with the given code and description, 400 Bad Request if Code is 0.
+type Error struct {
+	Parameters  *echosphere.ResponseParameters
+	Description string
+	Code        int
+}
+
+// Error returns the description of the error.
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// Handler implements a Bot API method, returning its result or an *Error.
+type Handler func(req Request) (any, error)
+
+// handler is the signature of the built-in method implementations.
+type handler func(ctx context.Context, req Request) (any, error)
+
+// chat is a chat known to the Server along with its messages.
+type chat struct {
+	messages map[int]*echosphere.Message
+	info     echosphere.Chat
+	next     int
+}
+
+// file is a file known to the Server.
+type file struct {
+	data []byte
+	info echosphere.File
+	name string
+}
+
+// Server is a fake Telegram Bot API server.
+// Any token is accepted, the methods not implemented reply with a 404 error
+// unless a Handler is registered for them with Handle.
+type Server struct {
+	*httptest.Server
+	handlers map[string]Handler
+	builtins map[string]handler
+	chats    map[int64]*chat
+	files    map[string]*file
+	paths    map[string]*file
+	notify   chan struct{}
+	done     chan struct{}
+	webhook  webhook
+	requests []Request
+	updates  []echosphere.Update
+	bot      echosphere.User
+	mu       sync.Mutex
+	updateID int
+	nfiles   int
+	once     sync.Once
+}
+
+type webhook struct {
+	url    string
+	secret string
+}
+
+// NewServer starts and returns a new Server.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		handlers: make(map[string]Handler),
+		chats:    make(map[int64]*chat),
+		files:    make(map[string]*file),
+		paths:    make(map[string]*file),
+		notify:   make(chan struct{}),
+		done:     make(chan struct{}),
+		bot: echosphere.User{
+			ID:        1,
+			IsBot:     true,
+			FirstName: "Echosphere",
+			Username:  "echosphere_test_bot",
+		},
+	}
+	s.builtins = s.methods()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Close shuts down the server, interrupting the pending long polling requests.
+func (s *Server) Close() {
+	s.once.Do(func() { close(s.done) })
+	s.Server.Close()
+}
+
+// SetBot sets the user returned by getMe and used as the sender of the messages sent by the bot.
+func (s *Server) SetBot(bot echosphere.User) {
+	s.mu.Lock()
+	s.bot = bot
+	s.mu.Unlock()
+}
+
+// SetChat sets the information of a chat, which is otherwise derived from its ID.
+func (s *Server) SetChat(info echosphere.Chat) {
+	s.mu.Lock()
+	s.chat(info.ID).info = info
+	s.mu.Unlock()
+}
+
+// Handle registers h as the implementation of method, replacing the built-in one if any.
+func (s *Server) Handle(method string, h Handler) {
+	s.mu.Lock()
+	s.handlers[method] = h
+	s.mu.Unlock()
+}
+
+// Requests returns all the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Calls returns the requests received so far for the given method, in order.
+func (s *Server) Calls(method string) (ret []Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.requests {
+		if r.Method == method {
+			ret = append(ret, r)
+		}
+	}
+	return
+}
+
+// Messages returns the messages currently in the chat with the given ID, sorted by ID.
+func (s *Server) Messages(chatID int64) []echosphere.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.chats[chatID]
+	if !ok {
+		return nil
+	}
+
+	ret := make([]echosphere.Message, 0, len(c.messages))
+	for _, m := range c.messages {
+		ret = append(ret, *m)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// Message returns the message with the given ID in the chat with the given ID.
+func (s *Server) Message(chatID int64, messageID int) (echosphere.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.chats[chatID]; ok {
+		if m, ok := c.messages[messageID]; ok {
+			return *m, true
+		}
+	}
+	return echosphere.Message{}, false
+}
+
+// AddFile stores a file with the given name and content, which can then be
+// sent by file_id, requested with getFile and downloaded.
+func (s *Server) AddFile(name string, data []byte) echosphere.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFile("documents", name, data).info
+}
+
+// FileData returns the content of the file with the given file_id.
+func (s *Server) FileData(fileID string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.files[fileID]; ok {
+		return f.data, true
+	}
+	return nil, false
+}
+
+// AddUpdate delivers u to the bot, assigning it the next update ID if it has none.
+// The update is sent to the webhook if one is set, otherwise it's queued for getUpdates.
+func (s *Server) AddUpdate(u echosphere.Update) error {
+	s.mu.Lock()
+	if u.ID == 0 {
+		s.updateID++
+		u.ID = s.updateID
+	} else if u.ID > s.updateID {
+		s.updateID = u.ID
+	}
+
+	if hook := s.webhook; hook.url != "" {
+		s.mu.Unlock()
+		return hook.deliver(u)
+	}
+
+	s.updates = append(s.updates, u)
+	close(s.notify)
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+	return nil
+}
+
+// deliver posts u to the webhook.
+func (w webhook) deliver(u echosphere.Update) error {
+	body, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", w.secret)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook replied with status %s", res.Status)
+	}
+	return nil
+}
+
+// ServeHTTP implements the Bot API endpoints.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+
+	if p, ok := cutPrefix(p, "file/bot"); ok {
+		s.serveFile(w, r, p)
+		return
+	}
+
+	p, ok := cutPrefix(p, "bot")
+	if !ok {
+		writeError(w, &Error{Code: http.StatusNotFound, Description: "Not Found"})
+		return
+	}
+
+	token, method := path.Split(strings.TrimSuffix(p, "/"))
+	req, err := parseRequest(r, strings.TrimSuffix(token, "/"), method)
+	if err != nil {
+		writeError(w, badRequest("%s", err))
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	custom, isCustom := s.handlers[method]
+	builtin, isBuiltin := s.builtins[method]
+	s.mu.Unlock()
+
+	var res any
+
+	switch {
+	case isCustom:
+		res, err = custom(req)
+	case isBuiltin:
+		res, err = builtin(r.Context(), req)
+	default:
+		err = &Error{Code: http.StatusNotFound, Description: "Not Found"}
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, res)
+}
+
+// serveFile serves the file at the given path, prefixed by the token.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, p string) {
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		p = p[i+1:]
+	}
+
+	s.mu.Lock()
+	f, ok := s.paths[p]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, &Error{Code: http.StatusNotFound, Description: "Not Found"})
+		return
+	}
+	http.ServeContent(w, r, f.name, time.Time{}, bytes.NewReader(f.data))
+}
+
+// parseRequest reads the parameters and files of a method call.
+func parseRequest(r *http.Request, token, method string) (Request, error) {
+	req := Request{
+		Method: method,
+		Token:  token,
+		Files:  make(map[string]UploadedFile),
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return req, err
+		}
+
+		for field, headers := range r.MultipartForm.File {
+			for _, h := range headers {
+				f, err := h.Open()
+				if err != nil {
+					return req, err
+				}
+				data, err := io.ReadAll(f)
+				f.Close()
+				if err != nil {
+					return req, err
+				}
+				req.Files[field] = UploadedFile{Name: h.Filename, Data: data}
+			}
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return req, err
+	}
+
+	req.Params = r.Form
+	return req, nil
+}
+
+func writeResult(w http.ResponseWriter, res any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Result any  `json:"result"`
+		Ok     bool `json:"ok"`
+	}{res, true})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Code: http.StatusInternalServerError, Description: err.Error()}
+	}
+
+	code := e.Code
+	if code == 0 {
+		code = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Parameters  *echosphere.ResponseParameters `json:"parameters,omitempty"`
+		Description string                         `json:"description"`
+		ErrorCode   int                            `json:"error_code"`
+		Ok          bool                           `json:"ok"`
+	}{e.Parameters, e.Description, code, false})
+}
+
+func badRequest(format string, a ...any) *Error {
+	return &Error{Code: http.StatusBadRequest, Description: "Bad Request: " + fmt.Sprintf(format, a...)}
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
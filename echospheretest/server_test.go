@@ -0,0 +1,215 @@
+package echospheretest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/animber-coder/echosphere/v3"
+)
+
+const token = "123:token"
+
+// disableChatLimit disables the per-chat rate limiter of echosphere for the duration of the test.
+func disableChatLimit(t *testing.T) {
+	echosphere.SetChatRequestLimit(0)
+	t.Cleanup(func() { echosphere.SetChatRequestLimit(echosphere.DefaultChatRequestLimit) })
+}
+
+func newAPI(t *testing.T) (*Server, echosphere.API) {
+	disableChatLimit(t)
+
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	return srv, echosphere.NewLocalAPI(srv.URL, token)
+}
+
+func TestSendMessage(t *testing.T) {
+	srv, api := newAPI(t)
+
+	res, err := api.SendMessage("<b>hello</b> world", 100, &echosphere.MessageOptions{ParseMode: echosphere.HTML})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Result.Text != "hello world" || len(res.Result.Entities) != 1 {
+		t.Fatalf("unexpected message %+v", res.Result)
+	}
+
+	msgs := srv.Messages(100)
+	if len(msgs) != 1 || msgs[0].ID != res.Result.ID {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+
+	calls := srv.Calls("sendMessage")
+	if len(calls) != 1 || calls[0].Token != token || calls[0].Params.Get("chat_id") != "100" {
+		t.Fatalf("unexpected requests %+v", calls)
+	}
+}
+
+func TestEditAndDelete(t *testing.T) {
+	srv, api := newAPI(t)
+
+	res, err := api.SendMessage("first", 101, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := echosphere.NewMessageID(101, res.Result.ID)
+
+	if _, err := api.EditMessageText("second", msg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := srv.Message(101, res.Result.ID); m.Text != "second" || m.EditDate == 0 {
+		t.Fatalf("unexpected edited message %+v", m)
+	}
+
+	var apiErr *echosphere.APIError
+	if _, err := api.EditMessageText("second", msg, nil); !errors.As(err, &apiErr) || apiErr.ErrorCode() != http.StatusBadRequest {
+		t.Fatalf("expected a not modified error, got %v", err)
+	}
+
+	if _, err := api.DeleteMessage(101, res.Result.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Message(101, res.Result.ID); ok {
+		t.Fatal("expected the message to be deleted")
+	}
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	srv, api := newAPI(t)
+
+	res, err := api.SendDocument(echosphere.NewInputFileBytes("file.txt", []byte("content")), 102, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := api.GetFile(res.Result.Document.FileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := api.DownloadFile(file.Result.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "content" || res.Result.Document.FileName != "file.txt" {
+		t.Fatalf("unexpected content %q of file %+v", data, res.Result.Document)
+	}
+
+	if calls := srv.Calls("sendDocument"); string(calls[0].Files["document"].Data) != "content" {
+		t.Fatalf("unexpected uploaded files %+v", calls[0].Files)
+	}
+
+	// The file can be sent again by file_id.
+	if _, err := api.SendPhoto(echosphere.NewInputFileID(file.Result.FileID), 102, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendMediaGroup(t *testing.T) {
+	_, api := newAPI(t)
+
+	res, err := api.SendMediaGroup(103, []echosphere.GroupableInputMedia{
+		echosphere.InputMediaPhoto{Type: echosphere.MediaTypePhoto, Media: echosphere.NewInputFileBytes("a.jpg", []byte("a"))},
+		echosphere.InputMediaPhoto{Type: echosphere.MediaTypePhoto, Media: echosphere.NewInputFileBytes("b.jpg", []byte("b"))},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Result) != 2 || res.Result[0].MediaGroupID == "" || res.Result[0].MediaGroupID != res.Result[1].MediaGroupID {
+		t.Fatalf("unexpected messages %+v", res.Result)
+	}
+}
+
+func TestGetUpdates(t *testing.T) {
+	srv, api := newAPI(t)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		srv.AddUpdate(echosphere.Update{Message: &echosphere.Message{Text: "/start"}})
+	}()
+
+	res, err := api.GetUpdates(&echosphere.UpdateOptions{Timeout: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Result) != 1 || res.Result[0].Message.Text != "/start" {
+		t.Fatalf("unexpected updates %+v", res.Result)
+	}
+
+	// The update is confirmed by the next call.
+	res, err = api.GetUpdates(&echosphere.UpdateOptions{Offset: res.Result[0].ID + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Result) != 0 {
+		t.Fatalf("unexpected updates %+v", res.Result)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	srv, api := newAPI(t)
+
+	updates := make(chan echosphere.Update, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var u echosphere.Update
+		json.NewDecoder(r.Body).Decode(&u)
+		updates <- u
+	}))
+	defer hook.Close()
+
+	if _, err := api.SetWebhook(hook.URL, false, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.AddUpdate(echosphere.Update{Message: &echosphere.Message{Text: "hook"}}); err != nil {
+		t.Fatal(err)
+	}
+	if u := <-updates; u.Message.Text != "hook" {
+		t.Fatalf("unexpected update %+v", u)
+	}
+
+	if _, err := api.GetUpdates(nil); err == nil {
+		t.Fatal("expected getUpdates to fail while the webhook is set")
+	}
+}
+
+func TestCustomHandler(t *testing.T) {
+	srv, api := newAPI(t)
+
+	srv.Handle("sendMessage", func(req Request) (any, error) {
+		return nil, &Error{
+			Code:        http.StatusTooManyRequests,
+			Description: "Too Many Requests: retry after 5",
+			Parameters:  &echosphere.ResponseParameters{RetryAfter: 5},
+		}
+	})
+
+	var apiErr *echosphere.APIError
+	if _, err := api.SendMessage("text", 104, nil); !errors.As(err, &apiErr) || apiErr.ErrorCode() != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 error, got %v", err)
+	}
+
+	if _, err := api.SendPoll(104, "question", nil, nil); err == nil {
+		t.Fatal("expected an error for a method not implemented")
+	}
+}
+
+func TestHandlerErrorWithoutCode(t *testing.T) {
+	srv, api := newAPI(t)
+
+	srv.Handle("sendMessage", func(req Request) (any, error) {
+		return nil, &Error{Description: "Bad Request: message text is empty"}
+	})
+
+	var apiErr *echosphere.APIError
+	if _, err := api.SendMessage("text", 105, nil); !errors.As(err, &apiErr) || apiErr.ErrorCode() != http.StatusBadRequest {
+		t.Fatalf("expected a 400 error, got %v", err)
+	}
+}
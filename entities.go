@@ -0,0 +1,883 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UTF16Len returns the length of s in UTF-16 code units,
+// which is the unit used by Telegram for entity offsets and text limits.
+func UTF16Len(s string) (n int) {
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return
+}
+
+// EntityText returns the portion of text covered by the given entity.
+func EntityText(text string, entity MessageEntity) string {
+	u := utf16.Encode([]rune(text))
+	start, end := clampRange(entity.Offset, entity.Offset+entity.Length, len(u))
+	return string(utf16.Decode(u[start:end]))
+}
+
+// EntitiesToHTML returns text formatted as HTML according to the given entities,
+// ready to be sent with the HTML parse mode.
+func EntitiesToHTML(text string, entities []MessageEntity) string {
+	return formatEntities(text, entities, htmlFormatter{})
+}
+
+// EntitiesToMarkdownV2 returns text formatted as MarkdownV2 according to the given entities,
+// ready to be sent with the MarkdownV2 parse mode.
+func EntitiesToMarkdownV2(text string, entities []MessageEntity) string {
+	return formatEntities(text, entities, markdownV2Formatter{})
+}
+
+// EntityText returns the portion of the message text or caption covered by the given entity.
+func (m Message) EntityText(entity *MessageEntity) string {
+	if m.Text != "" {
+		return EntityText(m.Text, *entity)
+	}
+	return EntityText(m.Caption, *entity)
+}
+
+// TextHTML returns the message text formatted as HTML according to its entities.
+func (m Message) TextHTML() string {
+	return EntitiesToHTML(m.Text, derefEntities(m.Entities))
+}
+
+// TextMarkdownV2 returns the message text formatted as MarkdownV2 according to its entities.
+func (m Message) TextMarkdownV2() string {
+	return EntitiesToMarkdownV2(m.Text, derefEntities(m.Entities))
+}
+
+// CaptionHTML returns the message caption formatted as HTML according to its caption entities.
+func (m Message) CaptionHTML() string {
+	return EntitiesToHTML(m.Caption, derefEntities(m.CaptionEntities))
+}
+
+// CaptionMarkdownV2 returns the message caption formatted as MarkdownV2 according to its caption entities.
+func (m Message) CaptionMarkdownV2() string {
+	return EntitiesToMarkdownV2(m.Caption, derefEntities(m.CaptionEntities))
+}
+
+func derefEntities(entities []*MessageEntity) []MessageEntity {
+	ret := make([]MessageEntity, 0, len(entities))
+
+	for _, e := range entities {
+		if e != nil {
+			ret = append(ret, *e)
+		}
+	}
+	return ret
+}
+
+func clampRange(start, end, max int) (int, int) {
+	if start < 0 {
+		start = 0
+	}
+	if end > max {
+		end = max
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
+
+func utf16String(u []uint16) string {
+	return string(utf16.Decode(u))
+}
+
+// sortEntities sorts the entities the way Telegram does: by offset and,
+// for entities starting at the same offset, outer ones first.
+func sortEntities(entities []MessageEntity) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+}
+
+// normalizeEntities returns a sorted copy of entities clamped to the text
+// boundaries and without the empty ones.
+func normalizeEntities(entities []MessageEntity, max int) []MessageEntity {
+	ret := make([]MessageEntity, 0, len(entities))
+
+	for _, e := range entities {
+		start, end := clampRange(e.Offset, e.Offset+e.Length, max)
+		if start == end {
+			continue
+		}
+		e.Offset, e.Length = start, end-start
+		ret = append(ret, e)
+	}
+
+	sortEntities(ret)
+	return ret
+}
+
+func isCodeEntity(t MessageEntityType) bool {
+	return t == CodeEntity || t == PreEntity
+}
+
+// entityFormatter is implemented by the markup languages supported by Telegram.
+type entityFormatter interface {
+	escape(s string) string
+	escapeCode(s string) string
+	wrap(e MessageEntity, inner string) string
+	join(b *strings.Builder, s string)
+}
+
+func formatEntities(text string, entities []MessageEntity, f entityFormatter) string {
+	var (
+		b strings.Builder
+		u = utf16.Encode([]rune(text))
+	)
+
+	formatRange(&b, u, normalizeEntities(entities, len(u)), 0, len(u), f)
+	return b.String()
+}
+
+func formatRange(b *strings.Builder, u []uint16, entities []MessageEntity, start, end int, f entityFormatter) {
+	pos := start
+
+	for len(entities) > 0 {
+		var (
+			e        = entities[0]
+			eEnd     = e.Offset + e.Length
+			children []MessageEntity
+			inner    strings.Builder
+		)
+		entities = entities[1:]
+
+		// Collect the entities nested into e, splitting the ones which
+		// partially overlap with it so that the markup is always well formed.
+		for len(entities) > 0 && entities[0].Offset < eEnd {
+			c := entities[0]
+			entities = entities[1:]
+
+			if cEnd := c.Offset + c.Length; cEnd > eEnd {
+				rest := c
+				rest.Offset, rest.Length = eEnd, cEnd-eEnd
+				entities = append(entities, rest)
+				sortEntities(entities)
+				c.Length = eEnd - c.Offset
+			}
+			children = append(children, c)
+		}
+
+		f.join(b, f.escape(utf16String(u[pos:e.Offset])))
+		if isCodeEntity(e.Type) {
+			inner.WriteString(f.escapeCode(utf16String(u[e.Offset:eEnd])))
+		} else {
+			formatRange(&inner, u, children, e.Offset, eEnd, f)
+		}
+		f.join(b, f.wrap(e, inner.String()))
+		pos = eEnd
+	}
+
+	f.join(b, f.escape(utf16String(u[pos:end])))
+}
+
+var htmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+type htmlFormatter struct{}
+
+func (htmlFormatter) escape(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+func (htmlFormatter) escapeCode(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+func (htmlFormatter) join(b *strings.Builder, s string) {
+	b.WriteString(s)
+}
+
+func (h htmlFormatter) wrap(e MessageEntity, inner string) string {
+	switch e.Type {
+	case BoldEntity:
+		return "<b>" + inner + "</b>"
+	case ItalicEntity:
+		return "<i>" + inner + "</i>"
+	case UnderlineEntity:
+		return "<u>" + inner + "</u>"
+	case StrikethroughEntity:
+		return "<s>" + inner + "</s>"
+	case SpoilerEntity:
+		return "<tg-spoiler>" + inner + "</tg-spoiler>"
+	case CodeEntity:
+		return "<code>" + inner + "</code>"
+	case PreEntity:
+		if e.Language != "" {
+			return `<pre><code class="language-` + h.escape(e.Language) + `">` + inner + "</code></pre>"
+		}
+		return "<pre>" + inner + "</pre>"
+	case TextLinkEntity:
+		return `<a href="` + h.escape(e.URL) + `">` + inner + "</a>"
+	case TextMentionEntity:
+		if e.User == nil {
+			return inner
+		}
+		return `<a href="tg://user?id=` + itoa(e.User.ID) + `">` + inner + "</a>"
+	case CustomEmojiEntity:
+		return `<tg-emoji emoji-id="` + h.escape(e.CustomEmojiID) + `">` + inner + "</tg-emoji>"
+	case BlockquoteEntity:
+		return "<blockquote>" + inner + "</blockquote>"
+	default:
+		return inner
+	}
+}
+
+var (
+	markdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`,
+		")", `\)`, "~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`,
+		"-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`,
+		"!", `\!`,
+	)
+	markdownV2CodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	markdownV2URLEscaper  = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+type markdownV2Formatter struct{}
+
+func (markdownV2Formatter) escape(s string) string {
+	return markdownV2Escaper.Replace(s)
+}
+
+func (markdownV2Formatter) escapeCode(s string) string {
+	return markdownV2CodeEscaper.Replace(s)
+}
+
+// join separates adjacent underscores with a carriage return since
+// MarkdownV2 would otherwise read them as an underline marker.
+func (markdownV2Formatter) join(b *strings.Builder, s string) {
+	if strings.HasPrefix(s, "_") && endsWithUnescaped(b.String(), '_') {
+		b.WriteByte('\r')
+	}
+	b.WriteString(s)
+}
+
+func (m markdownV2Formatter) wrap(e MessageEntity, inner string) string {
+	switch e.Type {
+	case BoldEntity:
+		return "*" + inner + "*"
+	case ItalicEntity:
+		return "_" + inner + underscoreSep(inner) + "_"
+	case UnderlineEntity:
+		return "__" + inner + underscoreSep(inner) + "__"
+	case StrikethroughEntity:
+		return "~" + inner + "~"
+	case SpoilerEntity:
+		return "||" + inner + "||"
+	case CodeEntity:
+		return "`" + inner + "`"
+	case PreEntity:
+		return "```" + e.Language + "\n" + inner + "```"
+	case TextLinkEntity:
+		return "[" + inner + "](" + markdownV2URLEscaper.Replace(e.URL) + ")"
+	case TextMentionEntity:
+		if e.User == nil {
+			return inner
+		}
+		return "[" + inner + "](tg://user?id=" + itoa(e.User.ID) + ")"
+	case CustomEmojiEntity:
+		return "![" + inner + "](tg://emoji?id=" + markdownV2URLEscaper.Replace(e.CustomEmojiID) + ")"
+	case BlockquoteEntity:
+		return ">" + strings.ReplaceAll(inner, "\n", "\n>")
+	default:
+		return inner
+	}
+}
+
+func underscoreSep(inner string) string {
+	if endsWithUnescaped(inner, '_') {
+		return "\r"
+	}
+	return ""
+}
+
+// endsWithUnescaped reports whether s ends with the character c not preceded by a backslash.
+func endsWithUnescaped(s string, c byte) bool {
+	if !strings.HasSuffix(s, string(c)) {
+		return false
+	}
+
+	n := 0
+	for i := len(s) - 2; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 0
+}
+
+// openEntity is an entity whose end marker hasn't been found yet while parsing.
+type openEntity struct {
+	typ    MessageEntityType
+	tag    string
+	attr   string
+	start  int
+	merged bool
+}
+
+// entityParser accumulates the plain text and the entities found while parsing a formatted string.
+type entityParser struct {
+	text     strings.Builder
+	stack    []openEntity
+	entities []MessageEntity
+	off      int
+}
+
+func (p *entityParser) writeRune(r rune) {
+	p.text.WriteRune(r)
+	p.off += utf16.RuneLen(r)
+}
+
+func (p *entityParser) writeString(s string) {
+	p.text.WriteString(s)
+	p.off += UTF16Len(s)
+}
+
+func (p *entityParser) push(o openEntity) {
+	o.start = p.off
+	p.stack = append(p.stack, o)
+}
+
+func (p *entityParser) add(e MessageEntity, start int) {
+	e.Offset, e.Length = start, p.off-start
+	p.entities = append(p.entities, e)
+}
+
+// find returns the index in the stack of the innermost open entity of type t, or -1.
+func (p *entityParser) find(t MessageEntityType) int {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].typ == t {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *entityParser) remove(i int) openEntity {
+	o := p.stack[i]
+	p.stack = append(p.stack[:i], p.stack[i+1:]...)
+	return o
+}
+
+func (p *entityParser) result() (string, []MessageEntity) {
+	var entities = make([]MessageEntity, 0, len(p.entities))
+
+	for _, e := range p.entities {
+		if e.Length > 0 {
+			entities = append(entities, e)
+		}
+	}
+	sortEntities(entities)
+	return p.text.String(), entities
+}
+
+// linkEntity returns the entity corresponding to a link pointing to the given URL.
+func linkEntity(url string) (MessageEntity, error) {
+	if id, ok := cutPrefix(url, "tg://user?id="); ok {
+		uid, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return MessageEntity{}, fmt.Errorf("can't parse entities: invalid user ID %q", id)
+		}
+		return MessageEntity{Type: TextMentionEntity, User: &User{ID: uid}}, nil
+	}
+	return MessageEntity{Type: TextLinkEntity, URL: url}, nil
+}
+
+// ParseHTML parses a string formatted with the HTML style supported by Telegram
+// and returns the plain text along with its entities.
+// It returns an error in the same cases in which Telegram would refuse to parse the string,
+// so it can be used to validate the formatting and to measure the length of a message before sending it.
+func ParseHTML(s string) (text string, entities []MessageEntity, err error) {
+	var p entityParser
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '<':
+			j := strings.IndexByte(s[i:], '>')
+			if j < 0 {
+				return "", nil, fmt.Errorf("can't parse entities: unclosed start tag at byte offset %d", i)
+			}
+			if err = p.htmlTag(s[i+1 : i+j]); err != nil {
+				return "", nil, err
+			}
+			i += j + 1
+
+		case '&':
+			r, n, e := htmlEntity(s[i:])
+			if e != nil {
+				return "", nil, fmt.Errorf("%w at byte offset %d", e, i)
+			}
+			p.writeRune(r)
+			i += n
+
+		default:
+			j := strings.IndexAny(s[i:], "<&")
+			if j < 0 {
+				j = len(s) - i
+			}
+			p.writeString(s[i : i+j])
+			i += j
+		}
+	}
+
+	if l := len(p.stack); l > 0 {
+		return "", nil, fmt.Errorf("can't parse entities: can't find end tag corresponding to start tag %q", p.stack[l-1].tag)
+	}
+
+	text, entities = p.result()
+	return
+}
+
+func (p *entityParser) htmlTag(tag string) error {
+	if name, ok := cutPrefix(tag, "/"); ok {
+		name = strings.ToLower(strings.TrimSpace(name))
+		l := len(p.stack)
+		if l == 0 || p.stack[l-1].tag != name {
+			return fmt.Errorf("can't parse entities: unmatched end tag %q", name)
+		}
+
+		o := p.remove(l - 1)
+		if o.merged {
+			return nil
+		}
+
+		e := MessageEntity{Type: o.typ}
+		switch o.typ {
+		case TextLinkEntity:
+			var err error
+			if e, err = linkEntity(o.attr); err != nil {
+				return err
+			}
+		case PreEntity:
+			e.Language = o.attr
+		case CustomEmojiEntity:
+			e.CustomEmojiID = o.attr
+		}
+		p.add(e, o.start)
+		return nil
+	}
+
+	name, attrs, err := parseHTMLTag(tag)
+	if err != nil {
+		return err
+	}
+
+	o := openEntity{tag: name}
+	switch name {
+	case "b", "strong":
+		o.typ = BoldEntity
+	case "i", "em":
+		o.typ = ItalicEntity
+	case "u", "ins":
+		o.typ = UnderlineEntity
+	case "s", "strike", "del":
+		o.typ = StrikethroughEntity
+	case "tg-spoiler":
+		o.typ = SpoilerEntity
+	case "span":
+		if attrs["class"] != "tg-spoiler" {
+			return fmt.Errorf(`can't parse entities: tag "span" must have class "tg-spoiler"`)
+		}
+		o.typ = SpoilerEntity
+	case "a":
+		href, ok := attrs["href"]
+		if !ok {
+			return fmt.Errorf(`can't parse entities: tag "a" must have attribute "href"`)
+		}
+		o.typ, o.attr = TextLinkEntity, href
+	case "code":
+		// A code block nested right at the beginning of a pre block only
+		// specifies the language of the latter.
+		if l := len(p.stack); l > 0 && p.stack[l-1].typ == PreEntity && p.stack[l-1].start == p.off {
+			if lang, ok := cutPrefix(attrs["class"], "language-"); ok {
+				p.stack[l-1].attr = lang
+				o.merged = true
+				break
+			}
+		}
+		o.typ = CodeEntity
+	case "pre":
+		o.typ = PreEntity
+	case "tg-emoji":
+		id, ok := attrs["emoji-id"]
+		if !ok {
+			return fmt.Errorf(`can't parse entities: tag "tg-emoji" must have attribute "emoji-id"`)
+		}
+		o.typ, o.attr = CustomEmojiEntity, id
+	case "blockquote":
+		o.typ = BlockquoteEntity
+	default:
+		return fmt.Errorf("can't parse entities: unsupported start tag %q", name)
+	}
+
+	p.push(o)
+	return nil
+}
+
+// parseHTMLTag splits the content of a start tag into its name and attributes.
+func parseHTMLTag(tag string) (name string, attrs map[string]string, err error) {
+	attrs = make(map[string]string)
+
+	i := strings.IndexAny(tag, " \t\n")
+	if i < 0 {
+		return strings.ToLower(tag), attrs, nil
+	}
+	name, tag = strings.ToLower(tag[:i]), tag[i:]
+
+	for {
+		tag = strings.TrimLeft(tag, " \t\n")
+		if tag == "" {
+			return
+		}
+
+		var key, val string
+		i := strings.IndexAny(tag, "= \t\n")
+		if i < 0 || tag[i] != '=' {
+			if i < 0 {
+				i = len(tag)
+			}
+			attrs[strings.ToLower(tag[:i])] = ""
+			tag = tag[i:]
+			continue
+		}
+		key, tag = strings.ToLower(tag[:i]), tag[i+1:]
+
+		if tag != "" && (tag[0] == '"' || tag[0] == '\'') {
+			j := strings.IndexByte(tag[1:], tag[0])
+			if j < 0 {
+				return "", nil, fmt.Errorf("can't parse entities: unclosed value of attribute %q", key)
+			}
+			val, tag = tag[1:j+1], tag[j+2:]
+		} else {
+			j := strings.IndexAny(tag, " \t\n")
+			if j < 0 {
+				j = len(tag)
+			}
+			val, tag = tag[:j], tag[j:]
+		}
+
+		if attrs[key], err = unescapeHTML(val); err != nil {
+			return
+		}
+	}
+}
+
+func unescapeHTML(s string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		if s[i] != '&' {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		r, n, err := htmlEntity(s[i:])
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(r)
+		i += n
+	}
+	return b.String(), nil
+}
+
+// htmlEntity decodes the HTML entity at the beginning of s and returns it along with its length in bytes.
+func htmlEntity(s string) (rune, int, error) {
+	end := strings.IndexByte(s, ';')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("can't parse entities: unescaped '&'")
+	}
+
+	switch name := s[1:end]; name {
+	case "lt":
+		return '<', end + 1, nil
+	case "gt":
+		return '>', end + 1, nil
+	case "amp":
+		return '&', end + 1, nil
+	case "quot":
+		return '"', end + 1, nil
+	default:
+		var (
+			n   uint64
+			err = fmt.Errorf("can't parse entities: unsupported HTML entity %q", s[:end+1])
+		)
+
+		switch {
+		case strings.HasPrefix(name, "#x"), strings.HasPrefix(name, "#X"):
+			n, err = strconv.ParseUint(name[2:], 16, 32)
+		case strings.HasPrefix(name, "#"):
+			n, err = strconv.ParseUint(name[1:], 10, 32)
+		}
+
+		// Surrogates and code points beyond the Unicode range can't be encoded.
+		if err != nil || !utf8.ValidRune(rune(n)) {
+			return 0, 0, fmt.Errorf("can't parse entities: unsupported HTML entity %q", s[:end+1])
+		}
+		return rune(n), end + 1, nil
+	}
+}
+
+const markdownV2Reserved = "_*[]()~`>#+-=|{}.!\\"
+
+// ParseMarkdownV2 parses a string formatted with the MarkdownV2 style supported by Telegram
+// and returns the plain text along with its entities.
+// It returns an error in the same cases in which Telegram would refuse to parse the string,
+// so it can be used to validate the formatting and to measure the length of a message before sending it.
+func ParseMarkdownV2(s string) (text string, entities []MessageEntity, err error) {
+	var (
+		p          entityParser
+		r          = []rune(s)
+		quoteStart = -1
+		lineStart  = true
+	)
+
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+
+		if lineStart {
+			lineStart = false
+
+			if c == '>' {
+				if quoteStart < 0 {
+					quoteStart = p.off
+				}
+				continue
+			}
+
+			// The blockquote doesn't include the line feed which closes it.
+			if quoteStart >= 0 {
+				p.off--
+				p.add(MessageEntity{Type: BlockquoteEntity}, quoteStart)
+				p.off++
+				quoteStart = -1
+			}
+		}
+
+		switch c {
+		case '\r':
+			// Carriage returns are used to separate ambiguous markers.
+
+		case '\n':
+			p.writeRune(c)
+			lineStart = true
+
+		case '\\':
+			if i+1 == len(r) || r[i+1] < 1 || r[i+1] > 126 {
+				return "", nil, fmt.Errorf(`can't parse entities: character '\' is reserved and must be escaped with the preceding '\'`)
+			}
+			i++
+			p.writeRune(r[i])
+
+		case '*':
+			p.toggle(BoldEntity)
+
+		case '~':
+			p.toggle(StrikethroughEntity)
+
+		case '_':
+			if i+1 < len(r) && r[i+1] == '_' {
+				p.toggle(UnderlineEntity)
+				i++
+			} else {
+				p.toggle(ItalicEntity)
+			}
+
+		case '|':
+			if i+1 == len(r) || r[i+1] != '|' {
+				return "", nil, reservedError(c)
+			}
+			p.toggle(SpoilerEntity)
+			i++
+
+		case '`':
+			if i+2 < len(r) && r[i+1] == '`' && r[i+2] == '`' {
+				i, err = p.markdownPre(r, i+3)
+			} else {
+				i, err = p.markdownCode(r, i+1)
+			}
+			if err != nil {
+				return "", nil, err
+			}
+
+		case '!':
+			if i+1 == len(r) || r[i+1] != '[' {
+				return "", nil, reservedError(c)
+			}
+			p.push(openEntity{typ: CustomEmojiEntity})
+			i++
+
+		case '[':
+			p.push(openEntity{typ: TextLinkEntity})
+
+		case ']':
+			if i, err = p.markdownLink(r, i+1); err != nil {
+				return "", nil, err
+			}
+
+		default:
+			if strings.ContainsRune(markdownV2Reserved, c) {
+				return "", nil, reservedError(c)
+			}
+			p.writeRune(c)
+		}
+	}
+
+	if quoteStart >= 0 {
+		p.add(MessageEntity{Type: BlockquoteEntity}, quoteStart)
+	}
+
+	if l := len(p.stack); l > 0 {
+		return "", nil, fmt.Errorf("can't parse entities: can't find end of %s entity", p.stack[l-1].typ)
+	}
+
+	text, entities = p.result()
+	return
+}
+
+func reservedError(c rune) error {
+	return fmt.Errorf(`can't parse entities: character '%c' is reserved and must be escaped with the preceding '\'`, c)
+}
+
+// toggle closes the innermost open entity of type t if any, otherwise it opens a new one.
+func (p *entityParser) toggle(t MessageEntityType) {
+	if i := p.find(t); i >= 0 {
+		o := p.remove(i)
+		p.add(MessageEntity{Type: t}, o.start)
+		return
+	}
+	p.push(openEntity{typ: t})
+}
+
+// markdownCode parses an inline code entity whose content starts at r[i]
+// and returns the index of its closing marker.
+func (p *entityParser) markdownCode(r []rune, i int) (int, error) {
+	start := p.off
+
+	for ; i < len(r); i++ {
+		switch r[i] {
+		case '\\':
+			if i+1 < len(r) {
+				i++
+			}
+			p.writeRune(r[i])
+		case '`':
+			p.add(MessageEntity{Type: CodeEntity}, start)
+			return i, nil
+		default:
+			p.writeRune(r[i])
+		}
+	}
+	return 0, fmt.Errorf("can't parse entities: can't find end of %s entity", CodeEntity)
+}
+
+// markdownPre parses a pre entity whose optional language starts at r[i]
+// and returns the index of the last rune of its closing marker.
+func (p *entityParser) markdownPre(r []rune, i int) (int, error) {
+	var (
+		lang  string
+		start = p.off
+	)
+
+	for j := i; j < len(r); j++ {
+		if r[j] == '\n' {
+			lang, i = string(r[i:j]), j+1
+			break
+		}
+		if r[j] == '`' {
+			break
+		}
+	}
+
+	for ; i < len(r); i++ {
+		switch {
+		case r[i] == '\\':
+			if i+1 < len(r) {
+				i++
+			}
+			p.writeRune(r[i])
+		case r[i] == '`' && i+2 < len(r) && r[i+1] == '`' && r[i+2] == '`':
+			p.add(MessageEntity{Type: PreEntity, Language: lang}, start)
+			return i + 2, nil
+		default:
+			p.writeRune(r[i])
+		}
+	}
+	return 0, fmt.Errorf("can't parse entities: can't find end of %s entity", PreEntity)
+}
+
+// markdownLink closes the innermost link, whose URL part starts at r[i],
+// and returns the index of the closing parenthesis.
+func (p *entityParser) markdownLink(r []rune, i int) (int, error) {
+	l := len(p.stack)
+	if l == 0 || (p.stack[l-1].typ != TextLinkEntity && p.stack[l-1].typ != CustomEmojiEntity) {
+		return 0, reservedError(']')
+	}
+	if i == len(r) || r[i] != '(' {
+		return 0, fmt.Errorf("can't parse entities: can't find URL of the link")
+	}
+
+	var url strings.Builder
+	for i++; i < len(r) && r[i] != ')'; i++ {
+		if r[i] == '\\' && i+1 < len(r) {
+			i++
+		}
+		url.WriteRune(r[i])
+	}
+	if i == len(r) {
+		return 0, fmt.Errorf("can't parse entities: can't find end of the URL")
+	}
+
+	o := p.remove(l - 1)
+	if o.typ == CustomEmojiEntity {
+		id, ok := cutPrefix(url.String(), "tg://emoji?id=")
+		if !ok {
+			return 0, fmt.Errorf("can't parse entities: invalid custom emoji URL %q", url.String())
+		}
+		p.add(MessageEntity{Type: CustomEmojiEntity, CustomEmojiID: id}, o.start)
+		return i, nil
+	}
+
+	e, err := linkEntity(url.String())
+	if err != nil {
+		return 0, err
+	}
+	p.add(e, o.start)
+	return i, nil
+}
@@ -0,0 +1,172 @@
+package echosphere
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type entityTest struct {
+	text     string
+	entities []MessageEntity
+	html     string
+	md       string
+}
+
+var entityTests = []entityTest{
+	{
+		text: "plain text.",
+		html: "plain text.",
+		md:   `plain text\.`,
+	},
+	{
+		text: "bold and italic",
+		entities: []MessageEntity{
+			{Type: BoldEntity, Offset: 0, Length: 15},
+			{Type: ItalicEntity, Offset: 9, Length: 6},
+		},
+		html: "<b>bold and <i>italic</i></b>",
+		md:   "*bold and _italic_*",
+	},
+	{
+		text: "👍 <tag> & co",
+		entities: []MessageEntity{
+			{Type: UnderlineEntity, Offset: 3, Length: 5},
+		},
+		html: "👍 <u>&lt;tag&gt;</u> &amp; co",
+		md:   `👍 __<tag\>__ & co`,
+	},
+	{
+		text: "link mention",
+		entities: []MessageEntity{
+			{Type: TextLinkEntity, Offset: 0, Length: 4, URL: "https://example.com/a_(b)"},
+			{Type: TextMentionEntity, Offset: 5, Length: 7, User: &User{ID: 42}},
+		},
+		html: `<a href="https://example.com/a_(b)">link</a> <a href="tg://user?id=42">mention</a>`,
+		md:   `[link](https://example.com/a_(b\)) [mention](tg://user?id=42)`,
+	},
+	{
+		text: "fmt.Println(`x`)",
+		entities: []MessageEntity{
+			{Type: PreEntity, Offset: 0, Length: 16, Language: "go"},
+		},
+		html: `<pre><code class="language-go">fmt.Println(` + "`x`" + `)</code></pre>`,
+		md:   "```go\nfmt.Println(\\`x\\`)```",
+	},
+	{
+		text: "quote\nline",
+		entities: []MessageEntity{
+			{Type: BlockquoteEntity, Offset: 0, Length: 10},
+			{Type: SpoilerEntity, Offset: 6, Length: 4},
+		},
+		html: "<blockquote>quote\n<tg-spoiler>line</tg-spoiler></blockquote>",
+		md:   ">quote\n>||line||",
+	},
+	{
+		text: "italic underline",
+		entities: []MessageEntity{
+			{Type: UnderlineEntity, Offset: 0, Length: 16},
+			{Type: ItalicEntity, Offset: 0, Length: 16},
+		},
+		html: "<u><i>italic underline</i></u>",
+		md:   "___italic underline_\r__",
+	},
+}
+
+func TestEntitiesToHTML(t *testing.T) {
+	for i, tt := range entityTests {
+		if res := EntitiesToHTML(tt.text, tt.entities); res != tt.html {
+			t.Fatalf("test #%d: expected %q, got %q", i, tt.html, res)
+		}
+	}
+}
+
+func TestEntitiesToMarkdownV2(t *testing.T) {
+	for i, tt := range entityTests {
+		if res := EntitiesToMarkdownV2(tt.text, tt.entities); res != tt.md {
+			t.Fatalf("test #%d: expected %q, got %q", i, tt.md, res)
+		}
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	for i, tt := range entityTests {
+		text, entities, err := ParseHTML(EntitiesToHTML(tt.text, tt.entities))
+		if err != nil {
+			t.Fatalf("test #%d: %v", i, err)
+		}
+		if text != tt.text {
+			t.Fatalf("test #%d: expected text %q, got %q", i, tt.text, text)
+		}
+		if !equalEntities(entities, tt.entities) {
+			t.Fatalf("test #%d: expected entities %+v, got %+v", i, tt.entities, entities)
+		}
+	}
+}
+
+func TestParseMarkdownV2(t *testing.T) {
+	for i, tt := range entityTests {
+		text, entities, err := ParseMarkdownV2(tt.md)
+		if err != nil {
+			t.Fatalf("test #%d: %v", i, err)
+		}
+		if text != tt.text {
+			t.Fatalf("test #%d: expected text %q, got %q", i, tt.text, text)
+		}
+		if !equalEntities(entities, tt.entities) {
+			t.Fatalf("test #%d: expected entities %+v, got %+v", i, tt.entities, entities)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"<b>unclosed", "<x>tag</x>", "<b><i>x</b></i>", "a & b", "&nbsp;", "<b>&#xD800;x</b>y", "&#x110000;", "&#55296;"} {
+		if _, _, err := ParseHTML(s); err == nil {
+			t.Fatalf("expected error parsing HTML %q", s)
+		}
+	}
+
+	for _, s := range []string{"*unclosed", "reserved.", "[link]", "`code", `trailing\`} {
+		if _, _, err := ParseMarkdownV2(s); err == nil {
+			t.Fatalf("expected error parsing MarkdownV2 %q", s)
+		}
+	}
+}
+
+func TestEntityText(t *testing.T) {
+	msg := Message{
+		Text:     "😀 hello #world",
+		Entities: []*MessageEntity{{Type: HashtagEntity, Offset: 9, Length: 6}},
+	}
+
+	if res := msg.EntityText(msg.Entities[0]); res != "#world" {
+		t.Fatalf("expected %q, got %q", "#world", res)
+	}
+
+	if l := UTF16Len(msg.Text); l != 15 {
+		t.Fatalf("expected UTF-16 length 15, got %d", l)
+	}
+}
+
+func equalEntities(a, b []MessageEntity) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(sortedEntities(a), sortedEntities(b))
+}
+
+func sortedEntities(entities []MessageEntity) []MessageEntity {
+	ret := append([]MessageEntity(nil), entities...)
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Offset != ret[j].Offset {
+			return ret[i].Offset < ret[j].Offset
+		}
+		if ret[i].Length != ret[j].Length {
+			return ret[i].Length > ret[j].Length
+		}
+		return ret[i].Type < ret[j].Type
+	})
+	return ret
+}
@@ -0,0 +1,291 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileIDStore stores the file_id assigned by Telegram to the uploaded files.
+// The keys identify the content of the files and the kind of media they were sent as.
+// Implementations must be safe for concurrent use.
+type FileIDStore interface {
+	Get(key string) (fileID string, ok bool)
+	Set(key, fileID string)
+	Delete(key string)
+}
+
+// memoryFileIDStore is a FileIDStore which keeps the file IDs in memory.
+type memoryFileIDStore struct {
+	ids map[string]string
+	mu  sync.RWMutex
+}
+
+// NewMemoryFileIDStore returns a FileIDStore which keeps the file IDs in memory.
+func NewMemoryFileIDStore() FileIDStore {
+	return &memoryFileIDStore{ids: make(map[string]string)}
+}
+
+func (m *memoryFileIDStore) Get(key string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	id, ok := m.ids[key]
+	return id, ok
+}
+
+func (m *memoryFileIDStore) Set(key, fileID string) {
+	m.mu.Lock()
+	m.ids[key] = fileID
+	m.mu.Unlock()
+}
+
+func (m *memoryFileIDStore) Delete(key string) {
+	m.mu.Lock()
+	delete(m.ids, key)
+	m.mu.Unlock()
+}
+
+// WithFileIDCache returns a copy of the API which caches in store the file_id of the files
+// uploaded from disk or from bytes, sending the file_id instead of the content the next times.
+// Files on disk are identified by path, size and modification time, bytes by their SHA-256 hash.
+// Files created with NewInputFileReader and thumbnails are never cached.
+// When Telegram rejects a cached file_id, it's removed from the store and the file is uploaded again.
+// The stickers added to the sets are first uploaded with UploadStickerFile, which returns their file_id.
+func (a API) WithFileIDCache(store FileIDStore) API {
+	a.cache = store
+	return a
+}
+
+// sendFiles calls send with files, each sent as the media of the corresponding kind,
+// replacing the cached ones with their file_id.
+// The send function returns the file_id of the files sent, in the same order,
+// which are stored in the cache for the next times.
+// The files sent along which aren't cached, like the thumbnails, are passed as extra.
+// A stale file_id is removed from the cache, and the files are sent again only if
+// none of them is read from an io.Reader, which the first attempt has consumed.
+func (a API) sendFiles(kinds []string, files, extra []InputFile, send func([]InputFile) ([]string, error)) error {
+	var (
+		hit  bool
+		keys = make([]string, len(files))
+		sent = make([]InputFile, len(files))
+	)
+
+	for i, f := range files {
+		sent[i] = a.localFile(f)
+
+		if key, ok := a.fileKey(kinds[i], f); ok {
+			keys[i] = key
+			if id, ok := a.cache.Get(key); ok {
+				sent[i] = NewInputFileID(id)
+				hit = true
+			}
+		}
+	}
+
+	ids, err := send(sent)
+	if hit && errors.Is(err, ErrInvalidFileID) {
+		for i, key := range keys {
+			if sent[i].id != "" && key != "" {
+				a.cache.Delete(key)
+				sent[i] = a.localFile(files[i])
+			}
+		}
+		if !hasReader(files) && !hasReader(extra) {
+			ids, err = send(sent)
+		}
+	}
+
+	if err == nil {
+		for i, key := range keys {
+			if key != "" && i < len(ids) && ids[i] != "" {
+				a.cache.Set(key, ids[i])
+			}
+		}
+	}
+	return err
+}
+
+// postMessageFile is like client.postFile for the methods which send a single media,
+// but goes through the file_id cache.
+func (a API) postMessageFile(endpoint, fileType string, file, thumbnail InputFile, vals url.Values, res *APIResponseMessage) error {
+	return a.sendFiles([]string{fileType}, []InputFile{file}, []InputFile{thumbnail}, func(files []InputFile) ([]string, error) {
+		*res = APIResponseMessage{}
+		// The thumbnails can only be uploaded, so they're never sent as a file:// URL.
+		err := a.client.postFile(a.base, endpoint, fileType, files[0], thumbnail, vals, res)
+		return []string{messageFileID(fileType, res.Result)}, err
+	})
+}
+
+// sendMedia calls send with media, whose files go through the file_id cache.
+// The send function returns the messages sent, in the same order as media.
+func (a API) sendMedia(media []InputMedia, send func([]InputMedia) ([]*Message, error)) error {
+	var (
+		kinds  = make([]string, len(media))
+		files  = make([]InputFile, len(media))
+		thumbs = make([]InputFile, len(media))
+	)
+
+	for i, m := range media {
+		if m != nil {
+			kinds[i], files[i], thumbs[i] = mediaKind(m), m.media(), m.thumbnail()
+		}
+	}
+
+	return a.sendFiles(kinds, files, thumbs, func(files []InputFile) (ids []string, err error) {
+		var sent = make([]InputMedia, len(media))
+
+		for i, m := range media {
+			if m != nil {
+				sent[i] = m.withFiles(files[i], m.thumbnail())
+			}
+		}
+
+		msgs, err := send(sent)
+		for i, m := range msgs {
+			if i < len(kinds) {
+				ids = append(ids, messageFileID(kinds[i], m))
+			}
+		}
+		return
+	})
+}
+
+// sendStickers calls send with stickers, whose files go through the file_id cache.
+// Since the methods of the sticker sets don't return the file_id of the stickers,
+// the files not cached yet are first uploaded with UploadStickerFile on behalf of userID.
+func (a API) sendStickers(userID int64, stickers []InputSticker, send func([]InputSticker) error) error {
+	for _, s := range stickers {
+		key, ok := a.fileKey("sticker", s.Sticker)
+		if !ok {
+			continue
+		}
+		if _, ok := a.cache.Get(key); ok {
+			continue
+		}
+		if _, err := a.UploadStickerFile(userID, s.Sticker, s.Format); err != nil {
+			return err
+		}
+	}
+
+	var (
+		kinds = make([]string, len(stickers))
+		files = make([]InputFile, len(stickers))
+	)
+
+	for i, s := range stickers {
+		kinds[i], files[i] = "sticker", s.Sticker
+	}
+
+	return a.sendFiles(kinds, files, nil, func(files []InputFile) ([]string, error) {
+		var sent = make([]InputSticker, len(stickers))
+
+		for i, s := range stickers {
+			s.Sticker = files[i]
+			sent[i] = s
+		}
+		return nil, send(sent)
+	})
+}
+
+// hasReader reports whether any of files is read from an io.Reader.
+func hasReader(files []InputFile) bool {
+	for _, f := range files {
+		if f.reader != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// fileKey returns the key identifying the content of f sent as kind in the cache,
+// if the API has a cache and f can be cached.
+func (a API) fileKey(kind string, f InputFile) (string, bool) {
+	if a.cache == nil || kind == "" || !f.isUpload() || f.reader != nil {
+		return "", false
+	}
+
+	if len(f.content) > 0 {
+		sum := sha256.Sum256(f.content)
+		return fmt.Sprintf("%s:sha256:%s", kind, hex.EncodeToString(sum[:])), true
+	}
+
+	path, err := filepath.Abs(f.path)
+	if err != nil {
+		return "", false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s:file:%s:%d:%d", kind, path, info.Size(), info.ModTime().UnixNano()), true
+}
+
+// mediaKind returns the kind of media m is sent as.
+func mediaKind(m InputMedia) string {
+	switch m.(type) {
+	case InputMediaPhoto:
+		return "photo"
+	case InputMediaVideo:
+		return "video"
+	case InputMediaAnimation:
+		return "animation"
+	case InputMediaAudio:
+		return "audio"
+	case InputMediaDocument:
+		return "document"
+	default:
+		return ""
+	}
+}
+
+// messageFileID returns the file_id of the media of the given kind in m.
+func messageFileID(kind string, m *Message) string {
+	if m == nil {
+		return ""
+	}
+
+	switch {
+	case kind == "photo" && len(m.Photo) > 0:
+		return m.Photo[len(m.Photo)-1].FileID
+	case kind == "audio" && m.Audio != nil:
+		return m.Audio.FileID
+	case kind == "document" && m.Document != nil:
+		return m.Document.FileID
+	case kind == "video" && m.Video != nil:
+		return m.Video.FileID
+	case kind == "animation" && m.Animation != nil:
+		return m.Animation.FileID
+	case kind == "voice" && m.Voice != nil:
+		return m.Voice.FileID
+	case kind == "video_note" && m.VideoNote != nil:
+		return m.VideoNote.FileID
+	case kind == "sticker" && m.Sticker != nil:
+		return m.Sticker.FileID
+	default:
+		return ""
+	}
+}
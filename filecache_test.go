@@ -0,0 +1,179 @@
+package echosphere
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// cacheServer replies to the uploads with a document whose file_id is "uploaded",
+// rejecting the file IDs listed in stale.
+func cacheServer(uploads *int, stale map[string]bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			*uploads++
+		} else if stale[r.URL.Query().Get("document")] {
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: wrong file identifier/HTTP URL specified"}`))
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "sendMediaGroup") {
+			w.Write([]byte(`{"ok":true,"result":[{"message_id":1,"photo":[{"file_id":"small"},{"file_id":"uploaded"}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"document":{"file_id":"uploaded"}}}`))
+	}))
+}
+
+func TestFileIDCache(t *testing.T) {
+	var uploads int
+
+	srv := cacheServer(&uploads, nil)
+	defer srv.Close()
+
+	store := NewMemoryFileIDStore()
+	a := NewLocalAPI(srv.URL, "123:abc").WithFileIDCache(store)
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.SendDocument(NewInputFileBytes("file.txt", []byte("content")), int64(1000+i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if uploads != 1 {
+		t.Fatalf("expected 1 upload, got %d", uploads)
+	}
+
+	// The same content sent as another kind of media isn't taken from the cache.
+	if _, err := a.SendMediaGroup(1010, []GroupableInputMedia{
+		InputMediaPhoto{Type: MediaTypePhoto, Media: NewInputFileBytes("file.txt", []byte("content"))},
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if uploads != 2 {
+		t.Fatalf("expected 2 uploads, got %d", uploads)
+	}
+
+	key, _ := a.fileKey("photo", NewInputFileBytes("file.txt", []byte("content")))
+	if id, _ := store.Get(key); id != "uploaded" {
+		t.Fatalf("expected the largest photo size to be cached, got %q", id)
+	}
+}
+
+func TestFileIDCacheStale(t *testing.T) {
+	var uploads int
+
+	srv := cacheServer(&uploads, map[string]bool{"expired": true})
+	defer srv.Close()
+
+	store := NewMemoryFileIDStore()
+	a := NewLocalAPI(srv.URL, "123:abc").WithFileIDCache(store)
+	file := NewInputFileBytes("file.txt", []byte("content"))
+
+	key, _ := a.fileKey("document", file)
+	store.Set(key, "expired")
+
+	if _, err := a.SendDocument(file, 1020, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if uploads != 1 {
+		t.Fatalf("expected the file to be uploaded again, got %d uploads", uploads)
+	}
+	if id, _ := store.Get(key); id != "uploaded" {
+		t.Fatalf("expected the new file ID to be cached, got %q", id)
+	}
+}
+
+func TestFileIDCacheDisabled(t *testing.T) {
+	var uploads int
+
+	srv := cacheServer(&uploads, nil)
+	defer srv.Close()
+
+	a := NewLocalAPI(srv.URL, "123:abc")
+	for i := 0; i < 2; i++ {
+		if _, err := a.SendDocument(NewInputFileBytes("file.txt", []byte("content")), int64(1030+i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if uploads != 2 {
+		t.Fatalf("expected 2 uploads, got %d", uploads)
+	}
+}
+
+func TestFileIDCacheStaleReader(t *testing.T) {
+	var requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: wrong file identifier/HTTP URL specified"}`))
+	}))
+	defer srv.Close()
+
+	store := NewMemoryFileIDStore()
+	a := NewLocalAPI(srv.URL, "123:abc").WithFileIDCache(store)
+	file := NewInputFileBytes("file.txt", []byte("content"))
+
+	key, _ := a.fileKey("document", file)
+	store.Set(key, "expired")
+
+	// The thumbnail read by the first request can't be sent again.
+	opts := &DocumentOptions{Thumbnail: NewInputFileReader("thumb.jpg", strings.NewReader("thumbnail"), 9)}
+	if _, err := a.SendDocument(file, 1021, opts); !errors.Is(err, ErrInvalidFileID) {
+		t.Fatalf("expected ErrInvalidFileID, got %v", err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got %d", requests)
+	}
+	if _, ok := store.Get(key); ok {
+		t.Fatal("expected the stale file ID to be removed from the cache")
+	}
+}
+
+func TestFileIDCacheStickers(t *testing.T) {
+	var (
+		uploads  int
+		stickers []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			uploads++
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/uploadStickerFile") {
+			w.Write([]byte(`{"ok":true,"result":{"file_id":"uploaded"}}`))
+			return
+		}
+		stickers = append(stickers, r.URL.Query().Get("sticker"))
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer srv.Close()
+
+	a := NewLocalAPI(srv.URL, "123:abc").WithFileIDCache(NewMemoryFileIDStore())
+	sticker := InputSticker{Sticker: NewInputFileBytes("sticker.png", []byte("sticker")), Format: StaticFormat, EmojiList: []string{"👍"}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.AddStickerToSet(1, "set", sticker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if uploads != 1 {
+		t.Fatalf("expected 1 upload, got %d", uploads)
+	}
+	for _, s := range stickers {
+		if !strings.Contains(s, `"sticker":"uploaded"`) {
+			t.Fatalf("expected the sticker to be sent by file_id, got %s", s)
+		}
+	}
+	if len(stickers) != 2 {
+		t.Fatalf("expected 2 stickers added, got %d", len(stickers))
+	}
+}
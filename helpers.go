@@ -19,65 +19,107 @@
 package echosphere
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
-// content contains a file's name, its type and its data.
+// content contains a file's name, its type and its source, which is
+// either data in memory, a file on disk or a reader.
 type content struct {
-	fname string
-	ftype string
-	fdata []byte
+	reader   io.Reader
+	progress ProgressFunc
+	fname    string
+	ftype    string
+	fpath    string
+	fdata    []byte
+	size     int64
+}
+
+// writeTo streams the content to w.
+func (c content) writeTo(w io.Writer) error {
+	var r io.Reader
+
+	switch {
+	case c.reader != nil:
+		r = c.reader
+
+	case c.fpath != "":
+		f, err := os.Open(c.fpath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+
+	default:
+		r = bytes.NewReader(c.fdata)
+	}
+
+	if c.progress != nil {
+		w = &progressWriter{Writer: w, fn: c.progress, total: c.size}
+	}
+
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// progressWriter reports the number of bytes written through it.
+type progressWriter struct {
+	io.Writer
+	fn    ProgressFunc
+	total int64
+	sent  int64
+}
+
+func (p *progressWriter) Write(b []byte) (n int, err error) {
+	n, err = p.Writer.Write(b)
+	p.sent += int64(n)
+	p.fn(p.sent, p.total)
+	return
 }
 
 func check(r APIResponse) error {
 	if b := r.Base(); !b.Ok {
-		return &APIError{code: b.ErrorCode, desc: b.Description}
+		return &APIError{code: b.ErrorCode, desc: b.Description, params: b.Parameters}
 	}
 	return nil
 }
 
+// isUpload reports whether the file has to be uploaded as multipart form data.
+func (i InputFile) isUpload() bool {
+	return i.id == "" && i.url == "" && (i.path != "" || i.reader != nil)
+}
+
 func processMedia(media, thumbnail InputFile) (im mediaEnvelope, cnt []content, err error) {
 	switch {
 	case media.id != "":
-		im = mediaEnvelope{
-			media:     media.id,
-			thumbnail: "",
-		}
+		im.media = media.id
 
 	case media.url != "":
-		im = mediaEnvelope{
-			media:     media.url,
-			thumbnail: "",
-		}
+		im.media = media.url
 
-	case media.path != "" && len(media.content) == 0:
-		if media.content, media.path, err = readFile(media); err != nil {
+	case media.isUpload():
+		var c content
+		if c, err = toContent(filepath.Base(media.path), media); err != nil {
 			return
 		}
-		fallthrough
-
-	case media.path != "" && len(media.content) > 0:
-		cnt = append(cnt, content{media.path, media.path, media.content})
-		im = mediaEnvelope{
-			media:     fmt.Sprintf("attach://%s", media.path),
-			thumbnail: "",
-		}
+		cnt = append(cnt, c)
+		im.media = fmt.Sprintf("attach://%s", c.ftype)
 	}
 
-	switch {
-	case thumbnail.path != "" && len(thumbnail.content) == 0:
-		if thumbnail.content, thumbnail.path, err = readFile(thumbnail); err != nil {
+	if thumbnail.isUpload() {
+		var c content
+		if c, err = toContent(filepath.Base(thumbnail.path), thumbnail); err != nil {
 			return
 		}
-		fallthrough
-
-	case thumbnail.path != "" && len(thumbnail.content) > 0:
-		cnt = append(cnt, content{thumbnail.path, thumbnail.path, thumbnail.content})
-		im.thumbnail = fmt.Sprintf("attach://%s", thumbnail.path)
+		cnt = append(cnt, c)
+		im.thumbnail = fmt.Sprintf("attach://%s", c.ftype)
 	}
 
 	return
@@ -91,39 +133,43 @@ func processSticker(sticker InputFile) (se stickerEnvelope, cnt []content, err e
 	case sticker.url != "":
 		se.Sticker = sticker.url
 
-	case sticker.path != "" && len(sticker.content) == 0:
-		if sticker.content, sticker.path, err = readFile(sticker); err != nil {
+	case sticker.isUpload():
+		var c content
+		if c, err = toContent(filepath.Base(sticker.path), sticker); err != nil {
 			return
 		}
-		fallthrough
-
-	case sticker.path != "" && len(sticker.content) > 0:
-		cnt = append(cnt, content{sticker.path, sticker.path, sticker.content})
-		se.Sticker = fmt.Sprintf("attach://%s", sticker.path)
+		cnt = append(cnt, c)
+		se.Sticker = fmt.Sprintf("attach://%s", c.ftype)
 	}
 
 	return
 }
 
-func readFile(im InputFile) (content []byte, path string, err error) {
-	content, err = os.ReadFile(im.path)
-	if err != nil {
-		return
+// toContent returns the content to upload for f in the form field ftype.
+// Files on disk aren't read here, they're streamed later while sending the request.
+func toContent(ftype string, f InputFile) (content, error) {
+	c := content{
+		fname:    filepath.Base(f.path),
+		ftype:    ftype,
+		progress: f.progress,
 	}
-	path = filepath.Base(im.path)
 
-	return
-}
+	switch {
+	case f.reader != nil:
+		c.reader, c.size = f.reader, f.size
 
-func toContent(ftype string, f InputFile) (content, error) {
-	if f.path != "" && len(f.content) == 0 {
-		var err error
-		if f.content, f.path, err = readFile(f); err != nil {
+	case len(f.content) > 0:
+		c.fdata, c.size = f.content, int64(len(f.content))
+
+	default:
+		info, err := os.Stat(f.path)
+		if err != nil {
 			return content{}, err
 		}
+		c.fpath, c.size = f.path, info.Size()
 	}
 
-	return content{f.path, ftype, f.content}, nil
+	return c, nil
 }
 
 func toInputMedia(media []GroupableInputMedia) (ret []InputMedia) {
@@ -151,6 +197,15 @@ func joinURL(base, endpoint string, vals url.Values) (addr string, err error) {
 	return
 }
 
+// addQuery appends the escaped parameter key=value to the query string of addr.
+func addQuery(addr, key, value string) string {
+	sep := "?"
+	if strings.Contains(addr, "?") {
+		sep = "&"
+	}
+	return addr + sep + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
 func itoa(i int64) string {
 	return strconv.FormatInt(i, 10)
 }
@@ -162,3 +217,12 @@ func ftoa(f float64) string {
 func btoa(b bool) string {
 	return strconv.FormatBool(b)
 }
+
+// cutPrefix returns s without the provided leading prefix string
+// and reports whether it found the prefix.
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
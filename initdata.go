@@ -0,0 +1,272 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// These are the public keys used by Telegram to sign the Mini App init data,
+// in the production and in the test environment, for ValidateWebAppSignature.
+var (
+	WebAppPublicKey     = ed25519.PublicKey(mustDecodeHex("e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d"))
+	WebAppTestPublicKey = ed25519.PublicKey(mustDecodeHex("40055058a4ee38156a06562e52eece92a771bcd8346a8c4615cb7376eddf72ec"))
+)
+
+// These are the errors returned when the Mini App init data isn't valid.
+var (
+	ErrWebAppHash      = errors.New("invalid hash of the web app init data")
+	ErrWebAppSignature = errors.New("invalid signature of the web app init data")
+	ErrWebAppExpired   = errors.New("the web app init data has expired")
+)
+
+// WebAppUser contains the data of a Mini App user.
+type WebAppUser struct {
+	FirstName             string `json:"first_name"`
+	LastName              string `json:"last_name,omitempty"`
+	Username              string `json:"username,omitempty"`
+	LanguageCode          string `json:"language_code,omitempty"`
+	PhotoURL              string `json:"photo_url,omitempty"`
+	ID                    int64  `json:"id"`
+	IsBot                 bool   `json:"is_bot,omitempty"`
+	IsPremium             bool   `json:"is_premium,omitempty"`
+	AddedToAttachmentMenu bool   `json:"added_to_attachment_menu,omitempty"`
+	AllowsWriteToPM       bool   `json:"allows_write_to_pm,omitempty"`
+}
+
+// WebAppChat represents the chat where the Mini App has been opened from the attachment menu.
+type WebAppChat struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Username string `json:"username,omitempty"`
+	PhotoURL string `json:"photo_url,omitempty"`
+	ID       int64  `json:"id"`
+}
+
+// WebAppInitData contains the data transferred to the Mini App when it's opened,
+// available as Telegram.WebApp.initData.
+type WebAppInitData struct {
+	User         *WebAppUser
+	Receiver     *WebAppUser
+	Chat         *WebAppChat
+	AuthDate     time.Time
+	QueryID      string
+	ChatType     string
+	ChatInstance string
+	StartParam   string
+	Hash         string
+	Signature    string
+	CanSendAfter int
+}
+
+// ParseWebAppInitData parses the init data of a Mini App without validating it.
+func ParseWebAppInitData(initData string) (*WebAppInitData, error) {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
+	return parseInitData(vals)
+}
+
+// ValidateWebAppData validates the init data of a Mini App opened by the bot with the given token,
+// checking its hash and, if maxAge isn't 0, that it was signed less than maxAge ago,
+// then returns it parsed.
+func ValidateWebAppData(initData, token string, maxAge time.Duration) (*WebAppInitData, error) {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hex.DecodeString(vals.Get("hash"))
+	if err != nil {
+		return nil, ErrWebAppHash
+	}
+
+	secret := hmacSHA256([]byte("WebAppData"), []byte(token))
+	if !hmac.Equal(hmacSHA256(secret, []byte(dataCheckString(vals, "hash"))), hash) {
+		return nil, ErrWebAppHash
+	}
+	return checkInitData(vals, maxAge)
+}
+
+// ValidateWebAppSignature validates the init data of a Mini App opened by the bot with the given ID
+// without knowing its token, e.g. by a third party, checking the Ed25519 signature made by Telegram
+// with the private key of publicKey, either WebAppPublicKey or WebAppTestPublicKey.
+// If maxAge isn't 0 it also checks that the data was signed less than maxAge ago.
+func ValidateWebAppSignature(initData string, botID int64, publicKey ed25519.PublicKey, maxAge time.Duration) (*WebAppInitData, error) {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(vals.Get("signature"), "="))
+	if err != nil {
+		return nil, ErrWebAppSignature
+	}
+
+	msg := itoa(botID) + ":WebAppData\n" + dataCheckString(vals, "hash", "signature")
+	if !ed25519.Verify(publicKey, []byte(msg), sig) {
+		return nil, ErrWebAppSignature
+	}
+	return checkInitData(vals, maxAge)
+}
+
+type webAppKey struct{}
+
+// WebAppAuth returns a middleware which authenticates the requests of the Mini App opened by the bot
+// with the given token, with ValidateWebAppData.
+// The init data is expected in the Authorization header, in the form "tma <init data>".
+// The requests without valid init data are rejected with 401 Unauthorized,
+// otherwise the init data is put in their context, where it's returned by WebAppFromContext.
+func WebAppAuth(token string, maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			initData, ok := cutPrefix(r.Header.Get("Authorization"), "tma ")
+			if !ok {
+				http.Error(w, "missing web app init data", http.StatusUnauthorized)
+				return
+			}
+
+			data, err := ValidateWebAppData(initData, token, maxAge)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), webAppKey{}, data)))
+		})
+	}
+}
+
+// WebAppFromContext returns the init data put in ctx by WebAppAuth.
+func WebAppFromContext(ctx context.Context) (*WebAppInitData, bool) {
+	data, ok := ctx.Value(webAppKey{}).(*WebAppInitData)
+	return data, ok
+}
+
+// WebAppUserFromContext returns the user of the init data put in ctx by WebAppAuth.
+func WebAppUserFromContext(ctx context.Context) (*WebAppUser, bool) {
+	if data, ok := WebAppFromContext(ctx); ok && data.User != nil {
+		return data.User, true
+	}
+	return nil, false
+}
+
+// checkInitData checks the age of the validated init data and parses it.
+func checkInitData(vals url.Values, maxAge time.Duration) (*WebAppInitData, error) {
+	data, err := parseInitData(vals)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxAge > 0 && time.Since(data.AuthDate) > maxAge {
+		return nil, ErrWebAppExpired
+	}
+	return data, nil
+}
+
+func parseInitData(vals url.Values) (*WebAppInitData, error) {
+	data := &WebAppInitData{
+		QueryID:      vals.Get("query_id"),
+		ChatType:     vals.Get("chat_type"),
+		ChatInstance: vals.Get("chat_instance"),
+		StartParam:   vals.Get("start_param"),
+		Hash:         vals.Get("hash"),
+		Signature:    vals.Get("signature"),
+	}
+
+	for key, v := range map[string]any{"user": &data.User, "receiver": &data.Receiver, "chat": &data.Chat} {
+		if s := vals.Get(key); s != "" {
+			if err := json.Unmarshal([]byte(s), v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if s := vals.Get("auth_date"); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		data.AuthDate = time.Unix(sec, 0)
+	}
+
+	if s := vals.Get("can_send_after"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		data.CanSendAfter = n
+	}
+	return data, nil
+}
+
+// dataCheckString returns the fields in vals but the excluded ones, sorted and joined
+// in the form key=value with a line feed as separator.
+func dataCheckString(vals url.Values, exclude ...string) string {
+	var keys []string
+
+	for k := range vals {
+		if !contains(exclude, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = k + "=" + vals.Get(k)
+	}
+	return strings.Join(fields, "\n")
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
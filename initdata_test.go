@@ -0,0 +1,89 @@
+package echosphere
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The hash and the signature of initData have been computed with OpenSSL,
+// the latter with the Ed25519 key whose public part is initDataPublicKey.
+const (
+	initDataToken     = "123456:ABC-DEF"
+	initDataPublicKey = "bcdd7538736c028921b36b78090159b3ce8434e1be639d51c924ab321f82deaa"
+	initData          = "auth_date=1700000000&query_id=AAHdF6IQAAAAAN0XohDhrOrc&user=%7B%22id%22%3A279058397%2C%22first_name%22%3A%22Vladislav%22%2C%22last_name%22%3A%22Kibenko%22%2C%22username%22%3A%22vdkfrost%22%2C%22language_code%22%3A%22ru%22%2C%22is_premium%22%3Atrue%2C%22allows_write_to_pm%22%3Atrue%2C%22photo_url%22%3A%22https%3A%2F%2Ft.me%2Fi%2Fuserpic%2F320%2Fa.svg%22%7D&start_param=ref42&chat_type=sender&chat_instance=8428209589180549439&signature=vNaHkkipe_VU8hkeRqvgTG2dF3WfL23Cbni0lC1TkFmfxqKylHghi50Cz41ovwTkENcx9oeZkuEwccyFowgdAA&hash=13798dd867faa740f6ff0e59685695e52741aff96868ed0f7a4de7aff7ba7ed9"
+)
+
+func TestValidateWebAppData(t *testing.T) {
+	data, err := ValidateWebAppData(initData, initDataToken, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.User == nil || data.User.ID != 279058397 || !data.User.AllowsWriteToPM || data.QueryID != "AAHdF6IQAAAAAN0XohDhrOrc" ||
+		data.StartParam != "ref42" || data.ChatType != "sender" || data.AuthDate.Unix() != 1700000000 {
+		t.Fatalf("unexpected init data %+v", data)
+	}
+
+	if _, err := ValidateWebAppData(initData, "123456:other", 0); !errors.Is(err, ErrWebAppHash) {
+		t.Fatalf("expected ErrWebAppHash, got %v", err)
+	}
+	if _, err := ValidateWebAppData(strings.Replace(initData, "ref42", "ref43", 1), initDataToken, 0); !errors.Is(err, ErrWebAppHash) {
+		t.Fatalf("expected ErrWebAppHash, got %v", err)
+	}
+	if _, err := ValidateWebAppData(initData, initDataToken, time.Hour); !errors.Is(err, ErrWebAppExpired) {
+		t.Fatalf("expected ErrWebAppExpired, got %v", err)
+	}
+}
+
+func TestValidateWebAppSignature(t *testing.T) {
+	key, _ := hex.DecodeString(initDataPublicKey)
+
+	data, err := ValidateWebAppSignature(initData, 123456, ed25519.PublicKey(key), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.User.Username != "vdkfrost" {
+		t.Fatalf("unexpected user %+v", data.User)
+	}
+
+	if _, err := ValidateWebAppSignature(initData, 654321, ed25519.PublicKey(key), 0); !errors.Is(err, ErrWebAppSignature) {
+		t.Fatalf("expected ErrWebAppSignature, got %v", err)
+	}
+	if _, err := ValidateWebAppSignature(initData, 123456, WebAppPublicKey, 0); !errors.Is(err, ErrWebAppSignature) {
+		t.Fatalf("expected ErrWebAppSignature, got %v", err)
+	}
+}
+
+func TestWebAppAuth(t *testing.T) {
+	h := WebAppAuth(initDataToken, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := WebAppUserFromContext(r.Context())
+		if !ok {
+			t.Error("missing user in the context")
+			return
+		}
+		w.Write([]byte(user.FirstName))
+	}))
+
+	for auth, want := range map[string]int{
+		"":                      http.StatusUnauthorized,
+		"tma " + initData:       http.StatusOK,
+		"tma " + initData + "0": http.StatusUnauthorized,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want || (want == http.StatusOK && w.Body.String() != "Vladislav") {
+			t.Fatalf("unexpected response %d %q for %q", w.Code, w.Body, auth)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import "net/url"
+
+// Call is a call to a method of the Telegram Bot API, as seen by the interceptors.
+type Call struct {
+	// Response is where the response is decoded, e.g. a *APIResponseMessage.
+	Response APIResponse
+	// Params are the parameters of the method, sent in the query string.
+	Params url.Values
+	// Form are the parameters sent in the body of the request, used only by setWebhook.
+	Form url.Values
+	// Files are the files sent by the methods taking a single file, e.g. sendPhoto,
+	// keyed by the name of their field: the file itself and the thumbnail, if any.
+	Files map[string]InputFile
+	// Media are the media sent by sendMediaGroup and editMessageMedia.
+	Media []InputMedia
+	// Stickers are the stickers sent by the methods creating or editing a sticker set.
+	Stickers []InputSticker
+	// Endpoint is the name of the method, e.g. sendMessage.
+	Endpoint string
+}
+
+// Invoker performs a call.
+type Invoker func(call *Call) error
+
+// Interceptor intercepts the calls to the Telegram Bot API, which are performed by invoking next.
+// An Interceptor can modify the call before invoking next, inspect or modify call.Response after it,
+// invoke next several times to retry the call, or not invoke it at all to short-circuit it,
+// in which case it should fill call.Response itself.
+type Interceptor func(call *Call, next Invoker) error
+
+// WithInterceptors returns a copy of the API whose calls go through the given interceptors,
+// after the ones already set. The first interceptor is the outermost one.
+func (a API) WithInterceptors(interceptors ...Interceptor) API {
+	a.client = a.client.withInterceptors(interceptors)
+	return a
+}
+
+// withInterceptors returns a copy of c with the given interceptors appended to its own.
+func (c client) withInterceptors(interceptors []Interceptor) *client {
+	c.interceptors = append(c.interceptors[:len(c.interceptors):len(c.interceptors)], interceptors...)
+	return &c
+}
+
+// intercept performs call with invoke, through the interceptors of c.
+func (c client) intercept(call *Call, invoke Invoker) error {
+	if call.Params == nil {
+		call.Params = make(url.Values)
+	}
+
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		next, icpt := invoke, c.interceptors[i]
+		invoke = func(call *Call) error {
+			return icpt(call, next)
+		}
+	}
+	return invoke(call)
+}
@@ -0,0 +1,117 @@
+package echosphere
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInterceptorParams(t *testing.T) {
+	var notify atomic.Value
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notify.Store(r.URL.Query().Get("disable_notification"))
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"text":"text"}}`)
+	}))
+	defer srv.Close()
+
+	var order []string
+	api := NewLocalAPI(srv.URL, "123:token").WithInterceptors(
+		func(call *Call, next Invoker) error {
+			order = append(order, "outer")
+			return next(call)
+		},
+		func(call *Call, next Invoker) error {
+			order = append(order, "inner")
+			call.Params.Set("disable_notification", "true")
+			if err := next(call); err != nil {
+				return err
+			}
+
+			if res := call.Response.(*APIResponseMessage); res.Result.Text != "text" {
+				t.Fatalf("unexpected response %+v", res)
+			}
+			return nil
+		},
+	)
+
+	if _, err := api.SendMessage("text", 1070, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if notify.Load() != "true" {
+		t.Fatal("expected the interceptor to set disable_notification")
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("unexpected order %v", order)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer srv.Close()
+
+	var files []string
+	api := NewLocalAPI(srv.URL, "123:token").WithInterceptors(func(call *Call, next Invoker) error {
+		for k := range call.Files {
+			files = append(files, k)
+		}
+		return json.Unmarshal([]byte(`{"ok":true,"result":{"message_id":42}}`), call.Response)
+	})
+
+	res, err := api.SendDocument(NewInputFileBytes("file.txt", []byte("content")), 1071, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Result.ID != 42 || atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("expected the call to be short-circuited, got %+v after %d requests", res.Result, calls)
+	}
+	if len(files) != 1 || files[0] != "document" {
+		t.Fatalf("unexpected files %v", files)
+	}
+}
+
+func TestInterceptorRetry(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"ok":false,"error_code":500,"description":"Internal Server Error"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":true}`)
+	}))
+	defer srv.Close()
+
+	api := NewLocalAPI(srv.URL, "123:token").WithInterceptors(func(call *Call, next Invoker) error {
+		var apiErr *APIError
+
+		err := next(call)
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == 500 {
+			return next(call)
+		}
+		return err
+	})
+
+	if _, err := api.SetWebhook("example.com/hook", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+
+	// The interceptors of the original API aren't affected.
+	if n := len(NewLocalAPI(srv.URL, "123:token").client.interceptors); n != 0 {
+		t.Fatalf("expected no interceptors, got %d", n)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"context"
+	"io"
+)
+
+// BotAPI is the interface implemented by API, covering all the methods of the Telegram Bot API.
+// Depending on BotAPI, or on the smaller interfaces it's made of, instead of API allows
+// to replace it with a mock in tests, such as echospheretest.MockAPI.
+type BotAPI interface {
+	UpdatesAPI
+	BotSettingsAPI
+	MessagingAPI
+	FilesAPI
+	ChatAdminAPI
+	ForumAPI
+	StickersAPI
+	InlineAPI
+	PaymentsAPI
+	GamesAPI
+	PassportAPI
+}
+
+var _ BotAPI = API{}
+
+// UpdatesAPI groups the methods to receive the updates.
+type UpdatesAPI interface {
+	GetUpdates(opts *UpdateOptions) (APIResponseUpdate, error)
+	SetWebhook(webhookURL string, dropPendingUpdates bool, opts *WebhookOptions) (APIResponseBase, error)
+	DeleteWebhook(dropPendingUpdates bool) (APIResponseBase, error)
+	GetWebhookInfo() (APIResponseWebhook, error)
+}
+
+// BotSettingsAPI groups the methods to manage the bot itself.
+type BotSettingsAPI interface {
+	GetMe() (APIResponseUser, error)
+	LogOut() (APIResponseBool, error)
+	Close() (APIResponseBool, error)
+	SetMyCommands(opts *CommandOptions, commands ...BotCommand) (APIResponseBool, error)
+	DeleteMyCommands(opts *CommandOptions) (APIResponseBool, error)
+	GetMyCommands(opts *CommandOptions) (APIResponseCommands, error)
+	SetMyName(name, languageCode string) (APIResponseBool, error)
+	GetMyName(languageCode string) (APIResponseBotName, error)
+	SetMyDescription(description, languageCode string) (APIResponseBool, error)
+	GetMyDescription(languageCode string) (APIResponseBotDescription, error)
+	SetMyShortDescription(shortDescription, languageCode string) (APIResponseBool, error)
+	GetMyShortDescription(languageCode string) (APIResponseBotShortDescription, error)
+	SetMyDefaultAdministratorRights(opts *SetMyDefaultAdministratorRightsOptions) (APIResponseBool, error)
+	GetMyDefaultAdministratorRights(opts *GetMyDefaultAdministratorRightsOptions) (APIResponseChatAdministratorRights, error)
+	SetChatMenuButton(opts *SetChatMenuButtonOptions) (APIResponseBool, error)
+	GetChatMenuButton(opts *GetChatMenuButtonOptions) (APIResponseMenuButton, error)
+	GetBusinessConnection(businessConnectionID string) (APIResponseBusinessConnection, error)
+}
+
+// MessagingAPI groups the methods to send, edit and delete messages.
+type MessagingAPI interface {
+	SendMessage(text string, chatID int64, opts *MessageOptions) (APIResponseMessage, error)
+	SendLongMessage(text string, chatID int64, opts *MessageOptions) ([]APIResponseMessage, error)
+	SendLongCaption(chatID int64, caption string, send CaptionFunc, opts *MessageOptions) ([]APIResponseMessage, error)
+	ForwardMessage(chatID, fromChatID int64, messageID int, opts *ForwardOptions) (APIResponseMessage, error)
+	ForwardMessages(chatID, fromChatID int64, messageIDs []int, opts *ForwardOptions) (APIResponseMessageIDs, error)
+	CopyMessage(chatID, fromChatID int64, messageID int, opts *CopyOptions) (APIResponseMessageID, error)
+	CopyMessages(chatID, fromChatID int64, messageIDs []int, opts *CopyMessagesOptions) (APIResponseMessageIDs, error)
+	SendPhoto(file InputFile, chatID int64, opts *PhotoOptions) (APIResponseMessage, error)
+	SendAudio(file InputFile, chatID int64, opts *AudioOptions) (APIResponseMessage, error)
+	SendDocument(file InputFile, chatID int64, opts *DocumentOptions) (APIResponseMessage, error)
+	SendVideo(file InputFile, chatID int64, opts *VideoOptions) (APIResponseMessage, error)
+	SendAnimation(file InputFile, chatID int64, opts *AnimationOptions) (APIResponseMessage, error)
+	SendVoice(file InputFile, chatID int64, opts *VoiceOptions) (APIResponseMessage, error)
+	SendVideoNote(file InputFile, chatID int64, opts *VideoNoteOptions) (APIResponseMessage, error)
+	SendMediaGroup(chatID int64, media []GroupableInputMedia, opts *MediaGroupOptions) (APIResponseMessageArray, error)
+	SendAlbum(chatID int64, media []GroupableInputMedia, opts *AlbumOptions) ([]*Message, error)
+	SendLocation(chatID int64, latitude, longitude float64, opts *LocationOptions) (APIResponseMessage, error)
+	EditMessageLiveLocation(msg MessageIDOptions, latitude, longitude float64, opts *EditLocationOptions) (APIResponseMessage, error)
+	StopMessageLiveLocation(msg MessageIDOptions, opts *MessageReplyMarkup) (APIResponseMessage, error)
+	SendVenue(chatID int64, latitude, longitude float64, title, address string, opts *VenueOptions) (APIResponseMessage, error)
+	SendContact(phoneNumber, firstName string, chatID int64, opts *ContactOptions) (APIResponseMessage, error)
+	SendPoll(chatID int64, question string, options []InputPollOption, opts *PollOptions) (APIResponseMessage, error)
+	SendDice(chatID int64, emoji DiceEmoji, opts *BaseOptions) (APIResponseMessage, error)
+	SendChatAction(action ChatAction, chatID int64, opts *ChatActionOptions) (APIResponseBool, error)
+	SetMessageReaction(chatID int64, messageID int, opts *MessageReactionOptions) (APIResponseBool, error)
+	EditMessageText(text string, msg MessageIDOptions, opts *MessageTextOptions) (APIResponseMessage, error)
+	EditMessageCaption(msg MessageIDOptions, opts *MessageCaptionOptions) (APIResponseMessage, error)
+	EditMessageMedia(msg MessageIDOptions, media InputMedia, opts *MessageReplyMarkup) (APIResponseMessage, error)
+	EditMessageReplyMarkup(msg MessageIDOptions, opts *MessageReplyMarkup) (APIResponseMessage, error)
+	StopPoll(chatID int64, messageID int, opts *MessageReplyMarkup) (APIResponsePoll, error)
+	DeleteMessage(chatID int64, messageID int) (APIResponseBase, error)
+	DeleteMessages(chatID int64, messageIDs []int) (APIResponseBool, error)
+}
+
+// FilesAPI groups the methods to get and download files.
+type FilesAPI interface {
+	GetUserProfilePhotos(userID int64, opts *UserProfileOptions) (APIResponseUserProfile, error)
+	GetFile(fileID string) (APIResponseFile, error)
+	DownloadFile(filePath string) ([]byte, error)
+	DownloadFileTo(ctx context.Context, filePath string, w io.Writer) (int64, error)
+	ResumeDownload(ctx context.Context, filePath string, w io.Writer, offset int64) (int64, error)
+	OpenFile(ctx context.Context, filePath string) (io.ReadCloser, error)
+	DownloadByFileID(ctx context.Context, fileID string, w io.Writer) (*File, error)
+}
+
+// ChatAdminAPI groups the methods to manage chats and their members.
+type ChatAdminAPI interface {
+	BanChatMember(chatID, userID int64, opts *BanOptions) (APIResponseBool, error)
+	UnbanChatMember(chatID, userID int64, opts *UnbanOptions) (APIResponseBool, error)
+	RestrictChatMember(chatID, userID int64, permissions ChatPermissions, opts *RestrictOptions) (APIResponseBool, error)
+	PromoteChatMember(chatID, userID int64, opts *PromoteOptions) (APIResponseBool, error)
+	SetChatAdministratorCustomTitle(chatID, userID int64, customTitle string) (APIResponseBool, error)
+	BanChatSenderChat(chatID, senderChatID int64) (APIResponseBool, error)
+	UnbanChatSenderChat(chatID, senderChatID int64) (APIResponseBool, error)
+	SetChatPermissions(chatID int64, permissions ChatPermissions, opts *ChatPermissionsOptions) (APIResponseBool, error)
+	ExportChatInviteLink(chatID int64) (APIResponseString, error)
+	CreateChatInviteLink(chatID int64, opts *InviteLinkOptions) (APIResponseInviteLink, error)
+	EditChatInviteLink(chatID int64, inviteLink string, opts *InviteLinkOptions) (APIResponseInviteLink, error)
+	RevokeChatInviteLink(chatID int64, inviteLink string) (APIResponseInviteLink, error)
+	ApproveChatJoinRequest(chatID, userID int64) (APIResponseBool, error)
+	DeclineChatJoinRequest(chatID, userID int64) (APIResponseBool, error)
+	SetChatPhoto(file InputFile, chatID int64) (APIResponseBool, error)
+	DeleteChatPhoto(chatID int64) (APIResponseBool, error)
+	SetChatTitle(chatID int64, title string) (APIResponseBool, error)
+	SetChatDescription(chatID int64, description string) (APIResponseBool, error)
+	PinChatMessage(chatID int64, messageID int, opts *PinMessageOptions) (APIResponseBool, error)
+	UnpinChatMessage(chatID int64, messageID int) (APIResponseBool, error)
+	UnpinAllChatMessages(chatID int64) (APIResponseBool, error)
+	LeaveChat(chatID int64) (APIResponseBool, error)
+	GetChat(chatID int64) (APIResponseChat, error)
+	GetChatAdministrators(chatID int64) (APIResponseAdministrators, error)
+	GetChatMemberCount(chatID int64) (APIResponseInteger, error)
+	GetChatMember(chatID, userID int64) (APIResponseChatMember, error)
+	SetChatStickerSet(chatID int64, stickerSetName string) (APIResponseBool, error)
+	DeleteChatStickerSet(chatID int64) (APIResponseBool, error)
+	GetUserChatBoosts(chatID, userID int64) (APIResponseUserChatBoosts, error)
+}
+
+// ForumAPI groups the methods to manage the topics of forum supergroups.
+type ForumAPI interface {
+	CreateForumTopic(chatID int64, name string, opts *CreateTopicOptions) (APIResponseForumTopic, error)
+	EditForumTopic(chatID, messageThreadID int64, opts *EditTopicOptions) (APIResponseBool, error)
+	CloseForumTopic(chatID, messageThreadID int64) (APIResponseBool, error)
+	ReopenForumTopic(chatID, messageThreadID int64) (APIResponseBool, error)
+	DeleteForumTopic(chatID, messageThreadID int64) (APIResponseBool, error)
+	UnpinAllForumTopicMessages(chatID, messageThreadID int64) (APIResponseBool, error)
+	EditGeneralForumTopic(chatID int64, name string) (APIResponseBool, error)
+	CloseGeneralForumTopic(chatID int64) (APIResponseBool, error)
+	ReopenGeneralForumTopic(chatID int64) (APIResponseBool, error)
+	HideGeneralForumTopic(chatID int64) (APIResponseBool, error)
+	UnhideGeneralForumTopic(chatID int64) (APIResponseBool, error)
+	UnpinAllGeneralForumTopicMessages(chatID int64) (APIResponseBool, error)
+	GetForumTopicIconStickers() (APIResponseStickers, error)
+}
+
+// StickersAPI groups the methods to send stickers and manage sticker sets.
+type StickersAPI interface {
+	SendSticker(stickerID string, chatID int64, opts *StickerOptions) (APIResponseMessage, error)
+	GetStickerSet(name string) (APIResponseStickerSet, error)
+	GetCustomEmojiStickers(customEmojiIDs ...string) (APIResponseStickers, error)
+	UploadStickerFile(userID int64, sticker InputFile, format StickerFormat) (APIResponseFile, error)
+	CreateNewStickerSet(userID int64, name, title string, stickers []InputSticker, opts *NewStickerSetOptions) (APIResponseBool, error)
+	AddStickerToSet(userID int64, name string, sticker InputSticker) (APIResponseBool, error)
+	SetStickerPositionInSet(sticker string, position int) (APIResponseBase, error)
+	DeleteStickerFromSet(sticker string) (APIResponseBase, error)
+	ReplaceStickerInSet(userID int64, name, oldSticker string, sticker InputSticker) (APIResponseBool, error)
+	SetStickerEmojiList(sticker string, emojis []string) (APIResponseBool, error)
+	SetStickerKeywords(sticker string, keywords []string) (APIResponseBool, error)
+	SetStickerMaskPosition(sticker string, mask MaskPosition) (APIResponseBool, error)
+	SetStickerSetTitle(name, title string) (APIResponseBool, error)
+	SetStickerSetThumbnail(name string, userID int64, thumbnail InputFile, format StickerFormat) (APIResponseBase, error)
+	SetCustomEmojiStickerSetThumbnail(name, emojiID string) (APIResponseBool, error)
+	DeleteStickerSet(name string) (APIResponseBool, error)
+}
+
+// InlineAPI groups the methods to answer callback, inline and Web App queries.
+type InlineAPI interface {
+	AnswerCallbackQuery(callbackID string, opts *CallbackQueryOptions) (APIResponseBool, error)
+	AnswerInlineQuery(inlineQueryID string, results []InlineQueryResult, opts *InlineQueryOptions) (APIResponseBase, error)
+	AnswerWebAppQuery(webAppQueryID string, result InlineQueryResult) (APIResponseSentWebAppMessage, error)
+}
+
+// PaymentsAPI groups the methods to send invoices and answer payment queries.
+type PaymentsAPI interface {
+	SendInvoice(chatID int64, title, description, payload, providerToken, currency string, prices []LabeledPrice, opts *InvoiceOptions) (APIResponseMessage, error)
+	CreateInvoiceLink(title, description, payload, providerToken, currency string, prices []LabeledPrice, opts *CreateInvoiceLinkOptions) (APIResponseBase, error)
+	AnswerShippingQuery(shippingQueryID string, ok bool, opts *ShippingQueryOptions) (APIResponseBase, error)
+	AnswerPreCheckoutQuery(preCheckoutQueryID string, ok bool, opts *PreCheckoutOptions) (APIResponseBase, error)
+}
+
+// GamesAPI groups the methods to play games.
+type GamesAPI interface {
+	SendGame(gameShortName string, chatID int64, opts *BaseOptions) (APIResponseMessage, error)
+	SetGameScore(userID int64, score int, msgID MessageIDOptions, opts *GameScoreOptions) (APIResponseMessage, error)
+	GetGameHighScores(userID int64, opts MessageIDOptions) (APIResponseGameHighScore, error)
+}
+
+// PassportAPI groups the methods of Telegram Passport.
+type PassportAPI interface {
+	SetPassportDataErrors(userID int64, errors []PassportElementError) (APIResponseBool, error)
+}
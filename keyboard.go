@@ -0,0 +1,329 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import "fmt"
+
+// These are the limits enforced by Telegram on keyboards.
+const (
+	MaxCallbackDataLength    = 64
+	MaxInlineButtonsPerRow   = 8
+	MaxInlineButtons         = 100
+	MaxKeyboardButtonsPerRow = 12
+	MaxKeyboardButtons       = 300
+)
+
+// NewInlineButtonCallback returns an inline keyboard button that sends data in a callback query when pressed.
+func NewInlineButtonCallback(text, data string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+// NewInlineButtonURL returns an inline keyboard button that opens url when pressed.
+func NewInlineButtonURL(text, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, URL: url}
+}
+
+// NewInlineButtonWebApp returns an inline keyboard button that launches the Web App at url when pressed.
+func NewInlineButtonWebApp(text, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, WebApp: &WebAppInfo{URL: url}}
+}
+
+// NewInlineButtonLoginURL returns an inline keyboard button used to automatically authorize the user on a website.
+func NewInlineButtonLoginURL(text string, login LoginURL) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, LoginURL: &login}
+}
+
+// NewInlineButtonSwitchInlineQuery returns an inline keyboard button that prompts the user to select
+// one of their chats and inserts the bot's username and query in the input field.
+func NewInlineButtonSwitchInlineQuery(text, query string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, SwitchInlineQuery: query}
+}
+
+// NewInlineButtonSwitchInlineQueryCurrentChat returns an inline keyboard button that inserts
+// the bot's username and query in the input field of the current chat.
+func NewInlineButtonSwitchInlineQueryCurrentChat(text, query string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, SwitchInlineQueryCurrentChat: query}
+}
+
+// NewInlineButtonSwitchInlineQueryChosenChat returns an inline keyboard button that prompts the user
+// to select one of their chats of the specified type and inserts the bot's username and the query in the input field.
+func NewInlineButtonSwitchInlineQueryChosenChat(text string, chosen SwitchInlineQueryChosenChat) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, SwitchInlineQueryChosenChat: &chosen}
+}
+
+// NewInlineButtonGame returns an inline keyboard button that launches the game of the message.
+// It MUST always be the first button in the first row.
+func NewInlineButtonGame(text string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, CallbackGame: &CallbackGame{}}
+}
+
+// NewInlineButtonPay returns an inline keyboard button used to pay an invoice.
+// It MUST always be the first button in the first row.
+func NewInlineButtonPay(text string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, Pay: true}
+}
+
+// NewKeyboardButton returns a reply keyboard button that sends text as a message when pressed.
+func NewKeyboardButton(text string) KeyboardButton {
+	return KeyboardButton{Text: text}
+}
+
+// NewKeyboardButtonContact returns a reply keyboard button that sends the user's phone number when pressed.
+func NewKeyboardButtonContact(text string) KeyboardButton {
+	return KeyboardButton{Text: text, RequestContact: true}
+}
+
+// NewKeyboardButtonLocation returns a reply keyboard button that sends the user's current location when pressed.
+func NewKeyboardButtonLocation(text string) KeyboardButton {
+	return KeyboardButton{Text: text, RequestLocation: true}
+}
+
+// NewKeyboardButtonPoll returns a reply keyboard button that asks the user to create a poll of the given type.
+func NewKeyboardButtonPoll(text string, pollType PollType) KeyboardButton {
+	return KeyboardButton{Text: text, RequestPoll: &KeyboardButtonPollType{Type: pollType}}
+}
+
+// NewKeyboardButtonUsers returns a reply keyboard button that asks the user to share users matching the criteria.
+func NewKeyboardButtonUsers(text string, request KeyboardButtonRequestUsers) KeyboardButton {
+	return KeyboardButton{Text: text, RequestUsers: &request}
+}
+
+// NewKeyboardButtonChat returns a reply keyboard button that asks the user to share a chat matching the criteria.
+func NewKeyboardButtonChat(text string, request KeyboardButtonRequestChat) KeyboardButton {
+	return KeyboardButton{Text: text, RequestChat: &request}
+}
+
+// NewKeyboardButtonWebApp returns a reply keyboard button that launches the Web App at url when pressed.
+func NewKeyboardButtonWebApp(text, url string) KeyboardButton {
+	return KeyboardButton{Text: text, WebApp: &WebAppInfo{URL: url}}
+}
+
+// keyboardLayout holds the rows of buttons of a keyboard being built.
+type keyboardLayout[T any] struct {
+	rows    [][]T
+	columns int
+}
+
+func (k *keyboardLayout[T]) add(buttons ...T) {
+	for _, b := range buttons {
+		l := len(k.rows)
+		if l == 0 || (k.columns > 0 && len(k.rows[l-1]) >= k.columns) {
+			k.rows = append(k.rows, nil)
+			l++
+		}
+		k.rows[l-1] = append(k.rows[l-1], b)
+	}
+}
+
+func (k *keyboardLayout[T]) row(buttons ...T) {
+	k.rows = append(k.rows, nil)
+	k.add(buttons...)
+}
+
+// validate checks the number of buttons in the layout and calls check on each of them.
+func (k *keyboardLayout[T]) validate(perRow, total int, check func(T) error) error {
+	var n int
+
+	for i, row := range k.rows {
+		if len(row) > perRow {
+			return fmt.Errorf("row %d: too many buttons: %d, the maximum is %d", i, len(row), perRow)
+		}
+
+		for j, b := range row {
+			if err := check(b); err != nil {
+				return fmt.Errorf("row %d, button %d: %w", i, j, err)
+			}
+		}
+		n += len(row)
+	}
+
+	if n > total {
+		return fmt.Errorf("too many buttons: %d, the maximum is %d", n, total)
+	}
+	return nil
+}
+
+// InlineKeyboardBuilder is used to build an InlineKeyboardMarkup.
+type InlineKeyboardBuilder struct {
+	layout keyboardLayout[InlineKeyboardButton]
+}
+
+// NewInlineKeyboard returns a new InlineKeyboardBuilder.
+func NewInlineKeyboard() *InlineKeyboardBuilder {
+	return &InlineKeyboardBuilder{}
+}
+
+// Columns sets the number of buttons per row used by Add.
+// A value of 0, the default, means no limit.
+func (k *InlineKeyboardBuilder) Columns(n int) *InlineKeyboardBuilder {
+	k.layout.columns = n
+	return k
+}
+
+// Add adds the buttons to the last row, starting a new one whenever it's full.
+func (k *InlineKeyboardBuilder) Add(buttons ...InlineKeyboardButton) *InlineKeyboardBuilder {
+	k.layout.add(buttons...)
+	return k
+}
+
+// Row starts a new row with the given buttons.
+func (k *InlineKeyboardBuilder) Row(buttons ...InlineKeyboardButton) *InlineKeyboardBuilder {
+	k.layout.row(buttons...)
+	return k
+}
+
+// Build validates the keyboard against the Telegram limits and returns it.
+func (k *InlineKeyboardBuilder) Build() (InlineKeyboardMarkup, error) {
+	if err := k.layout.validate(MaxInlineButtonsPerRow, MaxInlineButtons, checkInlineButton); err != nil {
+		return InlineKeyboardMarkup{}, err
+	}
+	return InlineKeyboardMarkup{InlineKeyboard: k.layout.rows}, nil
+}
+
+func checkInlineButton(b InlineKeyboardButton) error {
+	var n int
+
+	if b.Text == "" {
+		return fmt.Errorf("the text can't be empty")
+	}
+	if l := len(b.CallbackData); l > MaxCallbackDataLength {
+		return fmt.Errorf("callback data too long: %d bytes, the maximum is %d", l, MaxCallbackDataLength)
+	}
+
+	for _, set := range []bool{
+		b.CallbackData != "",
+		b.URL != "",
+		b.WebApp != nil,
+		b.LoginURL != nil,
+		b.SwitchInlineQuery != "",
+		b.SwitchInlineQueryCurrentChat != "",
+		b.SwitchInlineQueryChosenChat != nil,
+		b.CallbackGame != nil,
+		b.Pay,
+	} {
+		if set {
+			n++
+		}
+	}
+
+	if n != 1 {
+		return fmt.Errorf("exactly one of the optional fields must be used, found %d", n)
+	}
+	return nil
+}
+
+// ReplyKeyboardBuilder is used to build a ReplyKeyboardMarkup.
+type ReplyKeyboardBuilder struct {
+	layout keyboardLayout[KeyboardButton]
+	markup ReplyKeyboardMarkup
+}
+
+// NewReplyKeyboard returns a new ReplyKeyboardBuilder.
+func NewReplyKeyboard() *ReplyKeyboardBuilder {
+	return &ReplyKeyboardBuilder{}
+}
+
+// Columns sets the number of buttons per row used by Add.
+// A value of 0, the default, means no limit.
+func (k *ReplyKeyboardBuilder) Columns(n int) *ReplyKeyboardBuilder {
+	k.layout.columns = n
+	return k
+}
+
+// Add adds the buttons to the last row, starting a new one whenever it's full.
+func (k *ReplyKeyboardBuilder) Add(buttons ...KeyboardButton) *ReplyKeyboardBuilder {
+	k.layout.add(buttons...)
+	return k
+}
+
+// Row starts a new row with the given buttons.
+func (k *ReplyKeyboardBuilder) Row(buttons ...KeyboardButton) *ReplyKeyboardBuilder {
+	k.layout.row(buttons...)
+	return k
+}
+
+// Resize requests clients to resize the keyboard vertically for optimal fit.
+func (k *ReplyKeyboardBuilder) Resize() *ReplyKeyboardBuilder {
+	k.markup.ResizeKeyboard = true
+	return k
+}
+
+// OneTime requests clients to hide the keyboard as soon as it's been used.
+func (k *ReplyKeyboardBuilder) OneTime() *ReplyKeyboardBuilder {
+	k.markup.OneTimeKeyboard = true
+	return k
+}
+
+// Persistent requests clients to always show the keyboard when the regular keyboard is hidden.
+func (k *ReplyKeyboardBuilder) Persistent() *ReplyKeyboardBuilder {
+	k.markup.IsPersistent = true
+	return k
+}
+
+// Selective shows the keyboard only to the users mentioned in the message or to the sender of the replied message.
+func (k *ReplyKeyboardBuilder) Selective() *ReplyKeyboardBuilder {
+	k.markup.Selective = true
+	return k
+}
+
+// Placeholder sets the placeholder shown in the input field when the keyboard is active.
+func (k *ReplyKeyboardBuilder) Placeholder(text string) *ReplyKeyboardBuilder {
+	k.markup.InputFieldPlaceholder = text
+	return k
+}
+
+// Build validates the keyboard against the Telegram limits and returns it.
+func (k *ReplyKeyboardBuilder) Build() (ReplyKeyboardMarkup, error) {
+	if err := k.layout.validate(MaxKeyboardButtonsPerRow, MaxKeyboardButtons, checkKeyboardButton); err != nil {
+		return ReplyKeyboardMarkup{}, err
+	}
+	if l := UTF16Len(k.markup.InputFieldPlaceholder); l > 64 {
+		return ReplyKeyboardMarkup{}, fmt.Errorf("input field placeholder too long: %d characters, the maximum is 64", l)
+	}
+
+	markup := k.markup
+	markup.Keyboard = k.layout.rows
+	return markup, nil
+}
+
+func checkKeyboardButton(b KeyboardButton) error {
+	var n int
+
+	if b.Text == "" {
+		return fmt.Errorf("the text can't be empty")
+	}
+
+	for _, set := range []bool{
+		b.RequestUsers != nil,
+		b.RequestChat != nil,
+		b.RequestContact,
+		b.RequestLocation,
+		b.RequestPoll != nil,
+		b.WebApp != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+
+	if n > 1 {
+		return fmt.Errorf("at most one of the optional fields can be used, found %d", n)
+	}
+	return nil
+}
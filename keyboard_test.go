@@ -0,0 +1,70 @@
+package echosphere
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInlineKeyboardBuilder(t *testing.T) {
+	kbd, err := NewInlineKeyboard().
+		Columns(2).
+		Add(
+			NewInlineButtonCallback("test1", "test1"),
+			NewInlineButtonCallback("test2", "test2"),
+			NewInlineButtonCallback("test3", "test3"),
+		).
+		Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(kbd, inlineKeyboard) {
+		t.Logf("expected keyboard: %+v", inlineKeyboard)
+		t.Logf("got keyboard: %+v", kbd)
+		t.Fatal("error: keyboard mismatch")
+	}
+}
+
+func TestInlineKeyboardBuilderValidation(t *testing.T) {
+	tests := []*InlineKeyboardBuilder{
+		NewInlineKeyboard().Add(NewInlineButtonCallback("long", strings.Repeat("a", 65))),
+		NewInlineKeyboard().Add(InlineKeyboardButton{Text: "none"}),
+		NewInlineKeyboard().Add(InlineKeyboardButton{Text: "two", URL: "https://example.com", CallbackData: "data"}),
+		NewInlineKeyboard().Add(NewInlineButtonURL("", "https://example.com")),
+		NewInlineKeyboard().Row(make([]InlineKeyboardButton, 9)...),
+	}
+
+	for i, k := range tests {
+		if _, err := k.Build(); err == nil {
+			t.Fatalf("test #%d: expected validation error", i)
+		}
+	}
+}
+
+func TestReplyKeyboardBuilder(t *testing.T) {
+	kbd, err := NewReplyKeyboard().
+		Row(NewKeyboardButton("test 1"), NewKeyboardButton("test 2")).
+		Row(NewKeyboardButton("test 3"), NewKeyboardButton("test 4")).
+		Resize().
+		Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(kbd, keyboard) {
+		t.Logf("expected keyboard: %+v", keyboard)
+		t.Logf("got keyboard: %+v", kbd)
+		t.Fatal("error: keyboard mismatch")
+	}
+
+	_, err = NewReplyKeyboard().
+		Add(KeyboardButton{Text: "both", RequestContact: true, RequestLocation: true}).
+		Build()
+
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+}
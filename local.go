@@ -0,0 +1,100 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MigrateToLocal logs the bot out from the cloud Bot API server and returns an API
+// which uses the local server at url in --local mode, see NewLocalModeAPI.
+// After the log out the bot can't use the cloud server for 10 minutes.
+func (a API) MigrateToLocal(url string) (API, error) {
+	if _, err := a.LogOut(); err != nil {
+		return API{}, err
+	}
+	return NewLocalModeAPI(url, a.token), nil
+}
+
+// localRoot returns the address of the local server at url without the trailing bot<token> path.
+func localRoot(url, token string) string {
+	url = strings.TrimSuffix(url, "/")
+	return strings.TrimSuffix(url, "/bot"+token)
+}
+
+// isLocalFile reports whether filePath, as returned by GetFile, can be read from disk.
+func (a API) isLocalFile(filePath string) bool {
+	return a.local && filepath.IsAbs(filePath)
+}
+
+// openLocal opens the file on disk at filePath starting from offset.
+func (a API) openLocal(filePath string, offset int64) (*os.File, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if max := a.client.maxDownloadSize(); max > 0 && info.Size() > max {
+		f.Close()
+		return nil, ErrFileTooLarge
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// copyLocal copies the file on disk at filePath starting from offset to w.
+func (a API) copyLocal(filePath string, w io.Writer, offset int64) (int64, error) {
+	f, err := a.openLocal(filePath, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+// localFile returns f as a file:// URL, which a local server in --local mode reads from disk,
+// if f is a file on disk and the API is in local mode.
+// It's not used for the thumbnails, which Telegram accepts only as new uploads.
+func (a API) localFile(f InputFile) InputFile {
+	if !a.local || !f.isUpload() || f.reader != nil || len(f.content) > 0 {
+		return f
+	}
+
+	path, err := filepath.Abs(f.path)
+	if err != nil {
+		return f
+	}
+
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return NewInputFileURL(u.String())
+}
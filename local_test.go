@@ -0,0 +1,204 @@
+package echosphere
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewLocalAPI(t *testing.T) {
+	urls := []string{
+		"http://localhost:8081",
+		"http://localhost:8081/",
+		"http://localhost:8081/bot123:abc",
+		"http://localhost:8081/bot123:abc/",
+	}
+
+	for _, u := range urls {
+		a := NewLocalAPI(u, "123:abc")
+
+		if a.base != "http://localhost:8081/bot123:abc/" {
+			t.Fatalf("%s: unexpected base %q", u, a.base)
+		}
+		if f := a.fileURL("documents/file_0.pdf"); f != "http://localhost:8081/file/bot123:abc/documents/file_0.pdf" {
+			t.Fatalf("%s: unexpected file URL %q", u, f)
+		}
+	}
+}
+
+func TestLocalFile(t *testing.T) {
+	local := NewLocalModeAPI("http://localhost:8081", "123:abc")
+	path, _ := filepath.Abs("assets/tests/document.pdf")
+
+	if f := local.localFile(NewInputFilePath("assets/tests/document.pdf")); f.url != "file://"+filepath.ToSlash(path) {
+		t.Fatalf("unexpected file URL %q", f.url)
+	}
+
+	for _, f := range []InputFile{
+		NewInputFileID("id"),
+		NewInputFileBytes("file.txt", []byte("content")),
+		NewInputFileReader("file.txt", strings.NewReader("content"), 7),
+	} {
+		if r := local.localFile(f); r.url != "" {
+			t.Fatalf("unexpected file URL %q for %+v", r.url, f)
+		}
+	}
+
+	if f := NewLocalAPI("http://localhost:8081", "123:abc").localFile(NewInputFilePath("file.txt")); f.url != "" {
+		t.Fatalf("unexpected file URL %q outside of local mode", f.url)
+	}
+}
+
+func TestLocalDownload(t *testing.T) {
+	var buf bytes.Buffer
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("local content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewLocalModeAPI("http://localhost:0", "123:abc")
+	if _, err := a.ResumeDownload(context.Background(), path, &buf, 6); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "content" {
+		t.Fatalf("unexpected content %q", buf.String())
+	}
+}
+
+func TestLocalUpload(t *testing.T) {
+	var document, contentType string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		document = r.URL.Query().Get("document")
+		contentType = r.Header.Get("Content-Type")
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	a := NewLocalModeAPI(srv.URL, "123:abc")
+	if _, err := a.SendDocument(NewInputFilePath("assets/tests/document.pdf"), 1040, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(document, "file:///") || !strings.HasSuffix(document, "document.pdf") {
+		t.Fatalf("expected a file URL, got %q", document)
+	}
+	if contentType != "" {
+		t.Fatalf("expected no multipart body, got %q", contentType)
+	}
+}
+
+func TestLocalUploadEscape(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries = make(map[string]url.Values)
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries[path.Base(r.URL.Path)] = r.URL.Query()
+		mu.Unlock()
+
+		switch path.Base(r.URL.Path) {
+		case "sendDocument":
+			w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+		case "sendMediaGroup":
+			w.Write([]byte(`{"ok":true,"result":[{"message_id":1}]}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "a&b+c #1.txt")
+	if err := os.WriteFile(file, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewLocalModeAPI(srv.URL, "123:abc")
+	expected := a.localFile(NewInputFilePath(file)).url
+	if !strings.Contains(expected, "a&b+c") {
+		t.Fatalf("unexpected file URL %q", expected)
+	}
+
+	if _, err := a.SendDocument(NewInputFilePath(file), 1041, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.SendMediaGroup(1042, []GroupableInputMedia{
+		InputMediaDocument{Type: MediaTypeDocument, Media: NewInputFilePath(file)},
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	sticker := InputSticker{Sticker: NewInputFilePath(file), Format: StaticFormat, EmojiList: []string{"👍"}}
+	if _, err := a.AddStickerToSet(1043, "set", sticker); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.CreateNewStickerSet(1043, "set", "Set", []InputSticker{sticker, sticker}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		media    []struct{ Media string }
+		single   struct{ Sticker string }
+		stickers []struct{ Sticker string }
+	)
+	if document := queries["sendDocument"].Get("document"); document != expected {
+		t.Fatalf("sendDocument: expected %q, got %q", expected, document)
+	}
+	if err := json.Unmarshal([]byte(queries["sendMediaGroup"].Get("media")), &media); err != nil || len(media) != 1 || media[0].Media != expected {
+		t.Fatalf("sendMediaGroup: expected %q, got %+v %v", expected, media, err)
+	}
+	if err := json.Unmarshal([]byte(queries["addStickerToSet"].Get("sticker")), &single); err != nil || single.Sticker != expected {
+		t.Fatalf("addStickerToSet: expected %q, got %+v %v", expected, single, err)
+	}
+	if err := json.Unmarshal([]byte(queries["createNewStickerSet"].Get("stickers")), &stickers); err != nil || len(stickers) != 2 || stickers[1].Sticker != expected {
+		t.Fatalf("createNewStickerSet: expected %q, got %+v %v", expected, stickers, err)
+	}
+}
+
+func TestLocalThumbnail(t *testing.T) {
+	var (
+		document  string
+		thumbnail []byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		document = r.URL.Query().Get("document")
+		if f, _, err := r.FormFile("thumbnail"); err == nil {
+			thumbnail, _ = io.ReadAll(f)
+			f.Close()
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	thumb := filepath.Join(t.TempDir(), "thumb.jpg")
+	if err := os.WriteFile(thumb, []byte("thumbnail"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewLocalModeAPI(srv.URL, "123:abc")
+	opts := &DocumentOptions{Thumbnail: NewInputFilePath(thumb)}
+	if _, err := a.SendDocument(NewInputFilePath("assets/tests/document.pdf"), 1044, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(document, "file:///") {
+		t.Fatalf("expected the document as a file URL, got %q", document)
+	}
+	if string(thumbnail) != "thumbnail" {
+		t.Fatalf("expected the thumbnail to be uploaded, got %q", thumbnail)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Logger is the interface used by echosphere to log, with the structured fields
+// passed as alternating keys and values after the message.
+// It's implemented by *slog.Logger, which can be used directly on Go 1.21 and later.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+var (
+	deflogger Logger = stdLogger{}
+	logmu     sync.RWMutex
+)
+
+// tokenRegexp matches the bot tokens in the URLs of the Telegram Bot API.
+var tokenRegexp = regexp.MustCompile(`bot\d+:[\w-]+`)
+
+// SetLogger sets the Logger used by the API and Dispatcher instances which don't have their own.
+// By default the warnings and the errors are written with the standard log package,
+// while a nil Logger disables logging.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	logmu.Lock()
+	deflogger = l
+	logmu.Unlock()
+}
+
+func defaultLogger() Logger {
+	logmu.RLock()
+	defer logmu.RUnlock()
+	return deflogger
+}
+
+// WithLogger returns a copy of the API which logs with l instead of the default Logger.
+// The requests are logged at debug level, a nil Logger disables logging.
+func (a API) WithLogger(l Logger) API {
+	if l == nil {
+		l = nopLogger{}
+	}
+	a.client = a.client.withLogger(l)
+	return a
+}
+
+// redact replaces the bot tokens in s, e.g. in the errors which contain the URL of the request.
+func redact(s string) string {
+	return tokenRegexp.ReplaceAllString(s, "bot<token>")
+}
+
+// stdLogger writes the warnings and the errors with the standard log package.
+type stdLogger struct{}
+
+func (stdLogger) Debug(string, ...any) {}
+
+func (stdLogger) Info(string, ...any) {}
+
+func (stdLogger) Warn(msg string, args ...any) {
+	logStd("WARN", msg, args)
+}
+
+func (stdLogger) Error(msg string, args ...any) {
+	logStd("ERROR", msg, args)
+}
+
+func logStd(level, msg string, args []any) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "echosphere %s %s", level, msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			v := fmt.Sprint(args[i+1])
+			if strings.ContainsAny(v, " =\"") {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(&b, " %v=%s", args[i], v)
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	log.Println(b.String())
+}
+
+// nopLogger discards all the messages.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
@@ -0,0 +1,108 @@
+package echosphere
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type entry struct {
+	fields map[string]any
+	level  string
+	msg    string
+}
+
+type recLogger struct {
+	entries []entry
+	mu      sync.Mutex
+}
+
+func (r *recLogger) add(level, msg string, args []any) {
+	e := entry{level: level, msg: msg, fields: make(map[string]any)}
+	for i := 0; i+1 < len(args); i += 2 {
+		e.fields[fmt.Sprint(args[i])] = args[i+1]
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+}
+
+func (r *recLogger) Debug(msg string, args ...any) { r.add("debug", msg, args) }
+func (r *recLogger) Info(msg string, args ...any)  { r.add("info", msg, args) }
+func (r *recLogger) Warn(msg string, args ...any)  { r.add("warn", msg, args) }
+func (r *recLogger) Error(msg string, args ...any) { r.add("error", msg, args) }
+
+func TestLoggerRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`)
+	}))
+	defer srv.Close()
+
+	var (
+		rec = &recLogger{}
+		api = NewLocalAPI(srv.URL, "123:secret").WithLogger(rec)
+	)
+
+	if _, err := api.SendMessage("text", 1050, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(rec.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %+v", rec.entries)
+	}
+
+	e := rec.entries[0]
+	if e.level != "debug" || e.fields["method"] != "sendMessage" || e.fields["chat_id"] != "1050" || e.fields["error_code"] != 403 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if _, ok := e.fields["latency"]; !ok {
+		t.Fatalf("expected the latency in %+v", e)
+	}
+
+	// The default logger isn't affected.
+	if NewLocalAPI(srv.URL, "123:secret").client.logger != nil {
+		t.Fatal("expected the API to use the default logger")
+	}
+}
+
+func TestLoggerRedact(t *testing.T) {
+	var (
+		rec = &recLogger{}
+		api = NewLocalAPI("http://127.0.0.1:1", "123:secret").WithLogger(rec)
+	)
+
+	if _, err := api.GetMe(); err == nil {
+		t.Fatal("expected a network error")
+	}
+
+	if len(rec.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %+v", rec.entries)
+	}
+	if msg := fmt.Sprint(rec.entries[0].fields["error"]); msg == "" || strings.Contains(msg, "secret") {
+		t.Fatalf("expected the token to be redacted from %q", msg)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	stdLogger{}.Debug("hidden")
+	stdLogger{}.Info("hidden")
+	stdLogger{}.Error("can't get the updates", "error", errors.New("unexpected EOF"), "retry", 5)
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, `echosphere ERROR can't get the updates error="unexpected EOF" retry=5`) {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
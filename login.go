@@ -0,0 +1,99 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// These are the errors returned when the data of a Telegram login isn't valid.
+var (
+	ErrLoginHash    = errors.New("invalid hash of the login data")
+	ErrLoginExpired = errors.New("the login data has expired")
+)
+
+// LoginUser is the user authenticated with the Telegram Login Widget or a LoginURL button.
+type LoginUser struct {
+	AuthDate  time.Time
+	FirstName string
+	LastName  string
+	Username  string
+	PhotoURL  string
+	ID        int64
+}
+
+// ValidateLoginData validates the data received from the Telegram Login Widget or after a LoginURL button
+// for the bot with the given token, checking its hash and, if maxAge isn't 0, that the user was authenticated
+// less than maxAge ago, then returns the user.
+func ValidateLoginData(vals url.Values, token string, maxAge time.Duration) (*LoginUser, error) {
+	hash, err := hex.DecodeString(vals.Get("hash"))
+	if err != nil {
+		return nil, ErrLoginHash
+	}
+
+	secret := sha256.Sum256([]byte(token))
+	if !hmac.Equal(hmacSHA256(secret[:], []byte(dataCheckString(vals, "hash"))), hash) {
+		return nil, ErrLoginHash
+	}
+
+	id, err := strconv.ParseInt(vals.Get("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	authDate, err := strconv.ParseInt(vals.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &LoginUser{
+		ID:        id,
+		FirstName: vals.Get("first_name"),
+		LastName:  vals.Get("last_name"),
+		Username:  vals.Get("username"),
+		PhotoURL:  vals.Get("photo_url"),
+		AuthDate:  time.Unix(authDate, 0),
+	}
+	if maxAge > 0 && time.Since(user.AuthDate) > maxAge {
+		return nil, ErrLoginExpired
+	}
+	return user, nil
+}
+
+// LoginHandler returns the http.HandlerFunc for the redirect endpoint of the Telegram Login Widget
+// or of a LoginURL button, which validates the query parameters with ValidateLoginData
+// and calls onLogin with the authenticated user.
+// The requests with invalid data are rejected with 401 Unauthorized.
+func LoginHandler(token string, maxAge time.Duration, onLogin func(w http.ResponseWriter, r *http.Request, user *LoginUser)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := ValidateLoginData(r.URL.Query(), token, maxAge)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		onLogin(w, r, user)
+	}
+}
@@ -0,0 +1,55 @@
+package echosphere
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// The hash of loginData has been computed with OpenSSL for initDataToken.
+const loginData = "id=279058397&first_name=Vladislav&username=vdkfrost&photo_url=https%3A%2F%2Ft.me%2Fi%2Fuserpic%2F320%2Fa.jpg&auth_date=1700000000&hash=4801e161ef030d994c8c3c41a86fff911a59b3bb4463dfc24cbde6ea956302d9"
+
+func TestValidateLoginData(t *testing.T) {
+	vals, _ := url.ParseQuery(loginData)
+
+	user, err := ValidateLoginData(vals, initDataToken, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 279058397 || user.Username != "vdkfrost" || user.LastName != "" || user.AuthDate.Unix() != 1700000000 {
+		t.Fatalf("unexpected user %+v", user)
+	}
+
+	if _, err := ValidateLoginData(vals, "123456:other", 0); !errors.Is(err, ErrLoginHash) {
+		t.Fatalf("expected ErrLoginHash, got %v", err)
+	}
+	if _, err := ValidateLoginData(vals, initDataToken, time.Hour); !errors.Is(err, ErrLoginExpired) {
+		t.Fatalf("expected ErrLoginExpired, got %v", err)
+	}
+
+	vals.Set("username", "other")
+	if _, err := ValidateLoginData(vals, initDataToken, 0); !errors.Is(err, ErrLoginHash) {
+		t.Fatalf("expected ErrLoginHash, got %v", err)
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	h := LoginHandler(initDataToken, 0, func(w http.ResponseWriter, r *http.Request, user *LoginUser) {
+		w.Write([]byte(user.FirstName))
+	})
+
+	for query, want := range map[string]int{
+		loginData:          http.StatusOK,
+		loginData + "0":    http.StatusUnauthorized,
+		"id=1&auth_date=1": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodGet, "/login?"+query, nil))
+		if w.Code != want || (want == http.StatusOK && w.Body.String() != "Vladislav") {
+			t.Fatalf("unexpected response %d %q for %q", w.Code, w.Body, query)
+		}
+	}
+}
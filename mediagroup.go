@@ -0,0 +1,112 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MediaGroup is an album of messages sharing the same MediaGroupID,
+// delivered by the Dispatcher as a single update when enabled with SetMediaGroupWindow.
+type MediaGroup struct {
+	ID              string
+	Caption         string
+	CaptionEntities []*MessageEntity
+	Messages        []*Message
+}
+
+// MediaGroup returns the album the message of the update belongs to, with the messages
+// sorted by ID, or nil if the update hasn't been aggregated by the Dispatcher.
+func (u Update) MediaGroup() *MediaGroup {
+	return u.group
+}
+
+// groupMessage returns the message of u if it belongs to a media group.
+func groupMessage(u *Update) *Message {
+	for _, m := range []*Message{u.Message, u.ChannelPost, u.BusinessMessage} {
+		if m != nil && m.MediaGroupID != "" {
+			return m
+		}
+	}
+	return nil
+}
+
+// mediaGroups buffers the updates of the media groups until the window
+// since the first one has elapsed.
+type mediaGroups struct {
+	pending map[string][]*Update
+	window  time.Duration
+	mu      sync.Mutex
+}
+
+// add buffers u if it belongs to a media group and reports whether it did.
+// When the window of a new group elapses, the aggregated update is sent to out.
+func (g *mediaGroups) add(u *Update, out chan<- *Update) bool {
+	m := groupMessage(u)
+	if g.window <= 0 || m == nil || u.group != nil {
+		return false
+	}
+
+	key := strconv.FormatInt(m.Chat.ID, 10) + "/" + m.MediaGroupID
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pending == nil {
+		g.pending = make(map[string][]*Update)
+	}
+	if _, ok := g.pending[key]; !ok {
+		time.AfterFunc(g.window, func() { out <- g.flush(key) })
+	}
+	g.pending[key] = append(g.pending[key], u)
+	return true
+}
+
+// flush removes the updates of the given group and returns them aggregated
+// in a copy of the one with the first message.
+func (g *mediaGroups) flush(key string) *Update {
+	g.mu.Lock()
+	updates := g.pending[key]
+	delete(g.pending, key)
+	g.mu.Unlock()
+
+	sort.Slice(updates, func(i, j int) bool {
+		return groupMessage(updates[i]).ID < groupMessage(updates[j]).ID
+	})
+
+	var (
+		first = *updates[0]
+		group = &MediaGroup{Messages: make([]*Message, len(updates))}
+	)
+
+	for i, u := range updates {
+		m := groupMessage(u)
+		group.Messages[i] = m
+		// Telegram shows the caption of the album if only one of its items has got one.
+		if group.Caption == "" && m.Caption != "" {
+			group.Caption, group.CaptionEntities = m.Caption, m.CaptionEntities
+		}
+	}
+	group.ID = group.Messages[0].MediaGroupID
+	first.group = group
+	return &first
+}
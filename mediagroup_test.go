@@ -0,0 +1,53 @@
+package echosphere
+
+import (
+	"testing"
+	"time"
+)
+
+type albumBot chan *Update
+
+func (b albumBot) Update(u *Update) { b <- u }
+
+func TestMediaGroupWindow(t *testing.T) {
+	var (
+		bot = make(albumBot, 10)
+		d   = NewDispatcher("token", func(int64) Bot { return bot })
+	)
+	d.SetMediaGroupWindow(50 * time.Millisecond)
+
+	item := func(updateID, messageID int, caption string) *Update {
+		return &Update{ID: updateID, Message: &Message{
+			ID:           messageID,
+			Chat:         Chat{ID: 1100},
+			MediaGroupID: "album",
+			Caption:      caption,
+		}}
+	}
+
+	d.updates <- item(12, 3, "")
+	d.updates <- item(10, 1, "")
+	d.updates <- &Update{ID: 13, Message: &Message{ID: 4, Chat: Chat{ID: 1100}, Text: "text"}}
+	d.updates <- item(11, 2, "caption")
+
+	if u := <-bot; u.Message.Text != "text" || u.MediaGroup() != nil {
+		t.Fatalf("expected the text message first, got %+v", u)
+	}
+
+	u := <-bot
+	group := u.MediaGroup()
+	if u.ID != 10 || group == nil || group.ID != "album" || group.Caption != "caption" || len(group.Messages) != 3 {
+		t.Fatalf("unexpected update %+v with group %+v", u, group)
+	}
+	for i, m := range group.Messages {
+		if m.ID != i+1 {
+			t.Fatalf("unexpected order of the messages %+v", group.Messages)
+		}
+	}
+
+	select {
+	case u := <-bot:
+		t.Fatalf("unexpected update %+v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Migrator is an optional interface which can be implemented by the Bot instances
+// to be notified when their group chat is upgraded to a supergroup.
+// The Dispatcher moves the session to the ID of the supergroup, then calls Migrate
+// before passing it the update which reported the migration.
+type Migrator interface {
+	Migrate(fromChatID, toChatID int64)
+}
+
+// migration returns the IDs of the group and of the supergroup if u reports
+// the upgrade of a group chat to a supergroup.
+func migration(u *Update) (from, to int64, ok bool) {
+	if u.Message == nil {
+		return 0, 0, false
+	}
+
+	switch m := u.Message; {
+	case m.MigrateToChatID != 0:
+		return m.Chat.ID, int64(m.MigrateToChatID), true
+	case m.MigrateFromChatID != 0:
+		return int64(m.MigrateFromChatID), m.Chat.ID, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// migrate moves the session of the chat with ID from to the chat with ID to,
+// unless the latter has already got one, and reports whether it has been moved.
+func (d *Dispatcher) migrate(from, to int64) bool {
+	d.mu.Lock()
+	bot, ok := d.sessionMap[from]
+	if _, exists := d.sessionMap[to]; !ok || exists {
+		d.mu.Unlock()
+		return false
+	}
+	delete(d.sessionMap, from)
+	d.sessionMap[to] = bot
+	d.mu.Unlock()
+
+	if m, ok := bot.(Migrator); ok {
+		m.Migrate(from, to)
+	}
+	return true
+}
+
+// RetryMigratedChat returns an Interceptor which retries the calls failed because the chat
+// has been upgraded to a supergroup, replacing the chat_id parameter with the ID of the supergroup.
+// The function onMigrate, if not nil, is called with the old and new chat IDs before retrying.
+// The calls uploading files from an io.Reader aren't retried, as the reader has already been consumed.
+func RetryMigratedChat(onMigrate func(fromChatID, toChatID int64)) Interceptor {
+	return func(call *Call, next Invoker) error {
+		var apiErr *APIError
+
+		err := next(call)
+		if !errors.As(err, &apiErr) || apiErr.Parameters().MigrateToChatID == 0 || !call.replayable() {
+			return err
+		}
+
+		from, perr := strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+		if perr != nil {
+			return err
+		}
+
+		to := int64(apiErr.Parameters().MigrateToChatID)
+		if onMigrate != nil {
+			onMigrate(from, to)
+		}
+
+		call.Params.Set("chat_id", itoa(to))
+		return next(call)
+	}
+}
+
+// replayable reports whether the call can be performed again,
+// i.e. whether it doesn't upload any file from an io.Reader.
+func (c *Call) replayable() bool {
+	for _, f := range c.Files {
+		if f.reader != nil {
+			return false
+		}
+	}
+	for _, m := range c.Media {
+		if m.media().reader != nil || m.thumbnail().reader != nil {
+			return false
+		}
+	}
+	for _, s := range c.Stickers {
+		if s.Sticker.reader != nil {
+			return false
+		}
+	}
+	return true
+}
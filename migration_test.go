@@ -0,0 +1,110 @@
+package echosphere
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type migratingBot struct {
+	updates    chan *Update
+	migrations chan [2]int64
+	chatID     int64
+}
+
+func (m *migratingBot) Update(u *Update) {
+	m.updates <- u
+}
+
+func (m *migratingBot) Migrate(from, to int64) {
+	m.chatID = to
+	m.migrations <- [2]int64{from, to}
+}
+
+func TestDispatcherMigration(t *testing.T) {
+	var (
+		created = make(chan int64, 10)
+		bot     = &migratingBot{updates: make(chan *Update, 10), migrations: make(chan [2]int64, 10), chatID: -1080}
+		d       = NewDispatcher("token", func(chatID int64) Bot {
+			created <- chatID
+			if chatID == -1080 {
+				return bot
+			}
+			return &migratingBot{updates: make(chan *Update, 10), migrations: make(chan [2]int64, 10)}
+		})
+	)
+
+	d.updates <- &Update{Message: &Message{Chat: Chat{ID: -1080}, Text: "hello"}}
+	<-bot.updates
+
+	d.updates <- &Update{Message: &Message{Chat: Chat{ID: -1080}, MigrateToChatID: -1001080}}
+	if m := <-bot.migrations; m != [2]int64{-1080, -1001080} {
+		t.Fatalf("unexpected migration %v", m)
+	}
+	<-bot.updates
+
+	// The message in the new supergroup is dispatched to the same session.
+	d.updates <- &Update{Message: &Message{Chat: Chat{ID: -1001080}, MigrateFromChatID: -1080}}
+	<-bot.updates
+
+	if bot.chatID != -1001080 {
+		t.Fatalf("expected the bot to be migrated, got chat ID %d", bot.chatID)
+	}
+	if len(created) != 1 {
+		t.Fatalf("expected a single session to be created, got %d", len(created))
+	}
+
+	d.mu.Lock()
+	_, oldExists := d.sessionMap[-1080]
+	d.mu.Unlock()
+	if oldExists {
+		t.Fatal("expected the old session to be removed")
+	}
+}
+
+func TestRetryMigratedChat(t *testing.T) {
+	var chats []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chatID := r.URL.Query().Get("chat_id")
+		chats = append(chats, chatID)
+
+		if !strings.HasPrefix(chatID, "-100") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001081}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"chat":{"id":-1001081,"type":"supergroup"}}}`)
+	}))
+	defer srv.Close()
+
+	var migrated [2]int64
+	api := NewLocalAPI(srv.URL, "123:token").WithInterceptors(RetryMigratedChat(func(from, to int64) {
+		migrated = [2]int64{from, to}
+	}))
+
+	res, err := api.SendMessage("hello", -1081, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Result.Chat.ID != -1001081 || migrated != [2]int64{-1081, -1001081} {
+		t.Fatalf("unexpected result %+v after migration %v", res.Result, migrated)
+	}
+	if len(chats) != 2 || chats[1] != "-1001081" {
+		t.Fatalf("unexpected requests %v", chats)
+	}
+
+	// Without the interceptor the error is returned.
+	if _, err := NewLocalAPI(srv.URL, "123:token").SendMessage("hello", -1082, nil); err == nil {
+		t.Fatal("expected the migration error")
+	}
+
+	// Uploads from a reader aren't retried.
+	doc := NewInputFileReader("file.txt", strings.NewReader("content"), 7)
+	if _, err := api.SendDocument(doc, -1083, nil); err == nil {
+		t.Fatal("expected the migration error for an upload from a reader")
+	}
+}
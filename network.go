@@ -19,15 +19,13 @@
 package echosphere
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -35,12 +33,31 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// client performs the requests of an API.
+// All the clients share the same http.Client and rate limiters.
 type client struct {
+	*shared
+	logger       Logger
+	observer     Observer
+	interceptors []Interceptor
+	stats        *callStats
+}
+
+// callStats collects the statistics of a call while it's performed.
+type callStats struct {
+	wait   time.Duration
+	status int
+}
+
+// shared is the state shared by all the clients.
+type shared struct {
 	*http.Client
 	*sync.RWMutex
-	cl       map[string]*rate.Limiter // chat based limiter
-	gl       *rate.Limiter            // global limiter
-	climiter func() *rate.Limiter
+	cl          map[string]*rate.Limiter // chat based limiter
+	clmu        *sync.Mutex              // guards the insertions in cl
+	gl          *rate.Limiter            // global limiter
+	climiter    func() *rate.Limiter
+	maxDownload int64
 }
 
 var lclient = newClient()
@@ -53,6 +70,9 @@ func SetGlobalRequestLimit(d time.Duration) {
 	lclient.Unlock()
 }
 
+// DefaultChatRequestLimit is the default minimum interval between the requests to the same chat.
+const DefaultChatRequestLimit = time.Minute / 20
+
 // SetChatRequestLimit sets the per-chat rate limit for requests to the Telegram API.
 // A duration of 0 disables the rate limiter, allowing unlimited requests.
 func SetChatRequestLimit(d time.Duration) {
@@ -64,19 +84,96 @@ func SetChatRequestLimit(d time.Duration) {
 	lclient.Unlock()
 }
 
+// SetHTTPClient sets the http.Client used for the requests to the Telegram API,
+// e.g. to customize its transport or timeouts.
+// It should be called before making any request.
+func SetHTTPClient(c *http.Client) {
+	lclient.Lock()
+	lclient.Client = c
+	lclient.Unlock()
+}
+
 func newClient() *client {
 	return &client{
-		Client:  new(http.Client),
-		RWMutex: new(sync.RWMutex),
-		cl:      make(map[string]*rate.Limiter),
-		gl:      rate.NewLimiter(rate.Every(time.Second/30), 10),
-		climiter: func() *rate.Limiter {
-			return rate.NewLimiter(rate.Every(time.Minute/20), 1)
+		shared: &shared{
+			Client:  new(http.Client),
+			RWMutex: new(sync.RWMutex),
+			cl:      make(map[string]*rate.Limiter),
+			clmu:    new(sync.Mutex),
+			gl:      rate.NewLimiter(rate.Every(time.Second/30), 10),
+			climiter: func() *rate.Limiter {
+				return rate.NewLimiter(rate.Every(DefaultChatRequestLimit), 1)
+			},
 		},
 	}
 }
 
+// withLogger returns a copy of c which logs with l.
+func (c client) withLogger(l Logger) *client {
+	c.logger = l
+	return &c
+}
+
+// withObserver returns a copy of c which reports its calls to o.
+func (c client) withObserver(o Observer) *client {
+	c.observer = o
+	return &c
+}
+
+// log returns the Logger of c, or the default one if c has none.
+func (c client) log() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return defaultLogger()
+}
+
+// end logs at debug level and reports to the Observer the call to endpoint
+// started at start, which returned *err.
+// The statistics are read from c.stats, if tracked.
+func (c client) end(endpoint string, vals url.Values, start time.Time, err *error) {
+	var (
+		apiErr *APIError
+		ev     = RequestEvent{
+			Method:   endpoint,
+			ChatID:   vals.Get("chat_id"),
+			Duration: time.Since(start),
+			Err:      *err,
+		}
+	)
+
+	if c.stats != nil {
+		ev.Wait, ev.StatusCode = c.stats.wait, c.stats.status
+	}
+	if errors.As(*err, &apiErr) {
+		ev.ErrorCode = apiErr.ErrorCode()
+	}
+	c.obs().ObserveRequest(ev)
+
+	args := []any{"method", endpoint, "latency", ev.Duration}
+	if ev.ChatID != "" {
+		args = append(args, "chat_id", ev.ChatID)
+	}
+	if ev.Err != nil {
+		if ev.ErrorCode != 0 {
+			args = append(args, "error_code", ev.ErrorCode)
+		}
+		args = append(args, "error", redact(ev.Err.Error()))
+	}
+	c.log().Debug("request", args...)
+}
+
+func (c client) setStatus(status int) {
+	if c.stats != nil {
+		c.stats.status = status
+	}
+}
+
 func (c client) wait(chatID string) error {
+	if c.stats != nil {
+		defer func(start time.Time) { c.stats.wait = time.Since(start) }(time.Now())
+	}
+
 	c.RLock()
 	defer c.RUnlock()
 
@@ -84,15 +181,8 @@ func (c client) wait(chatID string) error {
 	// If the chatID is empty, it's a general API call like GetUpdates, GetMe
 	// and similar, so skip the per-chat request limit wait.
 	if chatID != "" {
-		// If no limiter exists for a chat, create one.
-		l, ok := c.cl[chatID]
-		if !ok {
-			l = c.climiter()
-			c.cl[chatID] = l
-		}
-
 		// Make sure to respect the single chat limit of requests.
-		if err := l.Wait(ctx); err != nil {
+		if err := c.chatLimiter(chatID).Wait(ctx); err != nil {
 			return err
 		}
 	}
@@ -101,11 +191,26 @@ func (c client) wait(chatID string) error {
 	return c.gl.Wait(ctx)
 }
 
+// chatLimiter returns the limiter of the given chat, creating it if none exists.
+// Several requests may share the read lock of c, so the map needs its own mutex.
+func (c client) chatLimiter(chatID string) *rate.Limiter {
+	c.clmu.Lock()
+	defer c.clmu.Unlock()
+
+	l, ok := c.cl[chatID]
+	if !ok {
+		l = c.climiter()
+		c.cl[chatID] = l
+	}
+	return l
+}
+
 func (c client) doGet(reqURL string) ([]byte, error) {
 	resp, err := c.Get(reqURL)
 	if err != nil {
 		return nil, err
 	}
+	c.setStatus(resp.StatusCode)
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
@@ -117,40 +222,82 @@ func (c client) doGet(reqURL string) ([]byte, error) {
 
 func (c client) doPost(reqURL string, files ...content) ([]byte, error) {
 	var (
-		buf = new(bytes.Buffer)
-		w   = multipart.NewWriter(buf)
+		pr, pw = io.Pipe()
+		w      = multipart.NewWriter(pw)
 	)
-
-	for _, f := range files {
-		part, err := w.CreateFormFile(f.ftype, filepath.Base(f.fname))
-		if err != nil {
-			return nil, err
+	defer pr.Close()
+
+	// The multipart body is streamed through a pipe so that the files
+	// never need to be fully loaded in memory.
+	go func() {
+		for _, f := range files {
+			part, err := w.CreateFormFile(f.ftype, f.fname)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			if err := f.writeTo(part); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
 		}
-		part.Write(f.fdata)
-	}
-	w.Close()
+		pw.CloseWithError(w.Close())
+	}()
 
-	req, err := http.NewRequest(http.MethodPost, reqURL, buf)
+	req, err := http.NewRequest(http.MethodPost, reqURL, pr)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Content-Type", w.FormDataContentType())
+	req.ContentLength = multipartLength(w.Boundary(), files)
 
 	res, err := c.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	c.setStatus(res.StatusCode)
 	defer res.Body.Close()
 	return io.ReadAll(res.Body)
 }
 
-func (c client) doPostForm(reqURL string, keyVals map[string]string) ([]byte, error) {
-	var form = make(url.Values)
+// multipartLength returns the length of the multipart body containing files
+// with the given boundary, or -1 if the size of any of the files is unknown.
+func multipartLength(boundary string, files []content) int64 {
+	var (
+		cw countWriter
+		w  = multipart.NewWriter(&cw)
+	)
 
-	for k, v := range keyVals {
-		form.Add(k, v)
+	if err := w.SetBoundary(boundary); err != nil {
+		return -1
 	}
 
+	for _, f := range files {
+		if f.size < 0 {
+			return -1
+		}
+		if _, err := w.CreateFormFile(f.ftype, f.fname); err != nil {
+			return -1
+		}
+		cw += countWriter(f.size)
+	}
+
+	if err := w.Close(); err != nil {
+		return -1
+	}
+	return int64(cw)
+}
+
+// countWriter counts the bytes written to it.
+type countWriter int64
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	*c += countWriter(len(b))
+	return len(b), nil
+}
+
+func (c client) doPostForm(reqURL string, form url.Values) ([]byte, error) {
 	req, err := http.NewRequest(http.MethodPost, reqURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, err
@@ -162,124 +309,126 @@ func (c client) doPostForm(reqURL string, keyVals map[string]string) ([]byte, er
 	if err != nil {
 		return nil, err
 	}
+	c.setStatus(res.StatusCode)
 	defer res.Body.Close()
 	return io.ReadAll(res.Body)
 }
 
-func (c client) sendFile(file, thumbnail InputFile, url, fileType string) (res []byte, err error) {
+func (c client) sendFile(file, thumbnail InputFile, reqURL, fileType string) (res []byte, err error) {
 	var cnt []content
 
-	if file.id != "" {
-		url = fmt.Sprintf("%s&%s=%s", url, fileType, file.id)
-	} else if file.url != "" {
-		url = fmt.Sprintf("%s&%s=%s", url, fileType, file.url)
-	} else if c, e := toContent(fileType, file); e == nil {
-		cnt = append(cnt, c)
-	} else {
-		err = e
+	switch {
+	case file.id != "":
+		reqURL = addQuery(reqURL, fileType, file.id)
+
+	case file.url != "":
+		reqURL = addQuery(reqURL, fileType, file.url)
+
+	default:
+		cf, err := toContent(fileType, file)
+		if err != nil {
+			return nil, err
+		}
+		cnt = append(cnt, cf)
 	}
 
-	if c, e := toContent("thumbnail", thumbnail); e == nil {
-		cnt = append(cnt, c)
-	} else {
-		err = e
+	if thumbnail.isUpload() {
+		ct, err := toContent("thumbnail", thumbnail)
+		if err != nil {
+			return nil, err
+		}
+		cnt = append(cnt, ct)
 	}
 
 	if len(cnt) > 0 {
-		res, err = c.doPost(url, cnt...)
-	} else {
-		res, err = c.doGet(url)
+		return c.doPost(reqURL, cnt...)
 	}
-	return
+	return c.doGet(reqURL)
 }
 
 func (c client) get(base, endpoint string, vals url.Values, v APIResponse) error {
-	url, err := url.JoinPath(base, endpoint)
-	if err != nil {
-		return err
-	}
+	call := &Call{Endpoint: endpoint, Params: vals, Response: v}
 
-	if vals != nil {
-		if queries := vals.Encode(); queries != "" {
-			url = fmt.Sprintf("%s?%s", url, queries)
-		}
-	}
-
-	if err := c.wait(vals.Get("chat_id")); err != nil {
-		return err
-	}
+	return c.intercept(call, func(call *Call) error {
+		return c.send(base, call, func(c client, url string) ([]byte, error) {
+			return c.doGet(url)
+		})
+	})
+}
 
-	cnt, err := c.doGet(url)
-	if err != nil {
-		return err
-	}
+func (c client) postForm(base, endpoint string, form, vals url.Values, v APIResponse) error {
+	call := &Call{Endpoint: endpoint, Params: vals, Form: form, Response: v}
 
-	if err := json.Unmarshal(cnt, v); err != nil {
-		return err
-	}
-	return check(v)
+	return c.intercept(call, func(call *Call) error {
+		return c.send(base, call, func(c client, url string) ([]byte, error) {
+			return c.doPostForm(url, call.Form)
+		})
+	})
 }
 
 func (c client) postFile(base, endpoint, fileType string, file, thumbnail InputFile, vals url.Values, v APIResponse) error {
-	url, err := joinURL(base, endpoint, vals)
-	if err != nil {
-		return err
+	call := &Call{
+		Endpoint: endpoint,
+		Params:   vals,
+		Files:    map[string]InputFile{fileType: file},
+		Response: v,
 	}
-
-	if err := c.wait(vals.Get("chat_id")); err != nil {
-		return err
-	}
-
-	cnt, err := c.sendFile(file, thumbnail, url, fileType)
-	if err != nil {
-		return err
+	if thumbnail.id != "" || thumbnail.url != "" || thumbnail.isUpload() {
+		call.Files["thumbnail"] = thumbnail
 	}
 
-	if err := json.Unmarshal(cnt, v); err != nil {
-		return err
-	}
-	return check(v)
+	return c.intercept(call, func(call *Call) error {
+		return c.send(base, call, func(c client, url string) ([]byte, error) {
+			return c.sendFile(call.Files[fileType], call.Files["thumbnail"], url, fileType)
+		})
+	})
 }
 
 func (c client) postMedia(base, endpoint string, editSingle bool, vals url.Values, v APIResponse, files ...InputMedia) error {
-	url, err := joinURL(base, endpoint, vals)
-	if err != nil {
-		return err
-	}
+	call := &Call{Endpoint: endpoint, Params: vals, Media: files, Response: v}
 
-	if err := c.wait(vals.Get("chat_id")); err != nil {
-		return err
-	}
+	return c.intercept(call, func(call *Call) error {
+		return c.send(base, call, func(c client, url string) ([]byte, error) {
+			return c.sendMediaFiles(url, editSingle, call.Media...)
+		})
+	})
+}
 
-	cnt, err := c.sendMediaFiles(url, editSingle, files...)
-	if err != nil {
-		return err
-	}
+func (c client) postStickers(base, endpoint string, vals url.Values, v APIResponse, stickers ...InputSticker) error {
+	call := &Call{Endpoint: endpoint, Params: vals, Stickers: stickers, Response: v}
 
-	if err := json.Unmarshal(cnt, v); err != nil {
-		return err
-	}
-	return check(v)
+	return c.intercept(call, func(call *Call) error {
+		return c.send(base, call, func(c client, url string) ([]byte, error) {
+			return c.sendStickers(url, call.Stickers...)
+		})
+	})
 }
 
-func (c client) postStickers(base, endpoint string, vals url.Values, v APIResponse, stickers ...InputSticker) error {
-	url, err := joinURL(base, endpoint, vals)
+// send performs the call to the Telegram API, after waiting for the rate limiters:
+// req sends the request to the url of the method, whose query string contains the parameters,
+// and returns the response body which is then decoded into call.Response.
+func (c client) send(base string, call *Call, req func(c client, url string) ([]byte, error)) (err error) {
+	c.stats = new(callStats)
+	defer c.end(call.Endpoint, call.Params, time.Now(), &err)
+
+	url, err := joinURL(base, call.Endpoint, call.Params)
 	if err != nil {
 		return err
 	}
 
-	if err := c.wait(vals.Get("chat_id")); err != nil {
+	if err := c.wait(call.Params.Get("chat_id")); err != nil {
 		return err
 	}
 
-	cnt, err := c.sendStickers(url, stickers...)
+	cnt, err := req(c, url)
 	if err != nil {
-		return err
+		return &RequestError{Endpoint: call.Endpoint, Err: err}
 	}
-	if err := json.Unmarshal(cnt, v); err != nil {
-		return err
+
+	if err := json.Unmarshal(cnt, call.Response); err != nil {
+		return &RequestError{Endpoint: call.Endpoint, StatusCode: c.stats.status, Err: err}
 	}
-	return check(v)
+	return check(call.Response)
 }
 
 func (c client) sendMediaFiles(url string, editSingle bool, files ...InputMedia) (res []byte, err error) {
@@ -317,7 +466,7 @@ func (c client) sendMediaFiles(url string, editSingle bool, files ...InputMedia)
 		return
 	}
 
-	url = fmt.Sprintf("%s&media=%s", url, jsn)
+	url = addQuery(url, "media", string(jsn))
 
 	if len(cnt) > 0 {
 		return c.doPost(url, cnt...)
@@ -349,10 +498,10 @@ func (c client) sendStickers(url string, stickers ...InputSticker) (res []byte,
 
 	if len(sti) == 1 {
 		jsn, _ = json.Marshal(sti[0])
-		url = fmt.Sprintf("%s&sticker=%s", url, jsn)
+		url = addQuery(url, "sticker", string(jsn))
 	} else {
 		jsn, _ = json.Marshal(sti)
-		url = fmt.Sprintf("%s&stickers=%s", url, jsn)
+		url = addQuery(url, "stickers", string(jsn))
 	}
 
 	if len(cnt) > 0 {
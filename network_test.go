@@ -0,0 +1,117 @@
+package echosphere
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// disableChatLimit disables the per-chat rate limiter for the duration of the test.
+func disableChatLimit(t *testing.T) {
+	SetChatRequestLimit(0)
+	t.Cleanup(func() { SetChatRequestLimit(DefaultChatRequestLimit) })
+}
+
+func uploadServer(t *testing.T, parts map[string]string, length *int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*length = r.ContentLength
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			data, _ := io.ReadAll(p)
+			parts[p.FormName()+"/"+p.FileName()] = string(data)
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+}
+
+func TestDoPostStreaming(t *testing.T) {
+	var (
+		length int64
+		sent   int64
+		parts  = make(map[string]string)
+		srv    = uploadServer(t, parts, &length)
+		path   = filepath.Join(t.TempDir(), "disk.txt")
+	)
+	defer srv.Close()
+
+	if err := os.WriteFile(path, []byte("from disk"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := toContent("document", NewInputFilePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, _ := toContent("thumbnail", NewInputFileBytes("mem.jpg", []byte("from memory")))
+	rdr, _ := toContent("extra", NewInputFileReader("reader.bin", strings.NewReader("from reader"), 11).WithProgress(func(s, _ int64) {
+		sent = s
+	}))
+
+	if _, err := newClient().doPost(srv.URL, file, mem, rdr); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"document/disk.txt": "from disk",
+		"thumbnail/mem.jpg": "from memory",
+		"extra/reader.bin":  "from reader",
+	}
+	for k, v := range expected {
+		if parts[k] != v {
+			t.Fatalf("part %s: expected %q, got %q", k, v, parts[k])
+		}
+	}
+
+	if length <= 0 {
+		t.Fatalf("expected a known content length, got %d", length)
+	}
+	if sent != 11 {
+		t.Fatalf("expected progress to report 11 bytes, got %d", sent)
+	}
+}
+
+func TestDoPostUnknownSize(t *testing.T) {
+	var (
+		length int64
+		parts  = make(map[string]string)
+		srv    = uploadServer(t, parts, &length)
+	)
+	defer srv.Close()
+
+	rdr, _ := toContent("document", NewInputFileReader("stream.txt", strings.NewReader("streamed"), -1))
+	if _, err := newClient().doPost(srv.URL, rdr); err != nil {
+		t.Fatal(err)
+	}
+
+	if parts["document/stream.txt"] != "streamed" {
+		t.Fatalf("unexpected parts %v", parts)
+	}
+	if length != -1 {
+		t.Fatalf("expected chunked encoding, got content length %d", length)
+	}
+}
+
+func TestDoPostMissingFile(t *testing.T) {
+	if _, err := toContent("document", NewInputFilePath("does/not/exist")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
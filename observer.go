@@ -0,0 +1,250 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Observer receives the events of the calls to the Telegram Bot API
+// and of the updates handled by the Dispatcher, e.g. to export metrics.
+// Its methods are called synchronously and concurrently, so they should be fast and thread-safe.
+type Observer interface {
+	ObserveRequest(RequestEvent)
+	ObserveUpdate(UpdateEvent)
+}
+
+// RequestEvent describes a call to a method of the Telegram Bot API.
+type RequestEvent struct {
+	// Err is the error returned by the call, if any.
+	Err error
+	// Method is the name of the method called, e.g. sendMessage.
+	Method string
+	// ChatID is the chat_id parameter of the call, if any.
+	ChatID string
+	// Duration is the total duration of the call, including Wait.
+	Duration time.Duration
+	// Wait is the time spent waiting for the rate limiters.
+	Wait time.Duration
+	// StatusCode is the HTTP status of the response, 0 if none was received.
+	StatusCode int
+	// ErrorCode is the error code returned by Telegram, 0 if the call succeeded.
+	ErrorCode int
+}
+
+// UpdateEvent describes an update handled by the Dispatcher.
+type UpdateEvent struct {
+	// Panic is the value the Update method of the Bot panicked with, if any.
+	// The Dispatcher keeps panicking after reporting it.
+	Panic any
+	// Type is the type of the update.
+	Type UpdateType
+	// ChatID is the chat ID the update has been dispatched to.
+	ChatID int64
+	// UpdateID is the ID of the update.
+	UpdateID int
+	// Duration is the time spent in the Update method of the Bot.
+	Duration time.Duration
+	// NewSession reports whether a new Bot instance has been created for the update.
+	NewSession bool
+}
+
+var (
+	defobserver Observer = nopObserver{}
+	obsmu       sync.RWMutex
+)
+
+// SetObserver sets the Observer used by the API and Dispatcher instances which don't have their own.
+// A nil Observer disables the events, which is the default.
+func SetObserver(o Observer) {
+	if o == nil {
+		o = nopObserver{}
+	}
+
+	obsmu.Lock()
+	defobserver = o
+	obsmu.Unlock()
+}
+
+func defaultObserver() Observer {
+	obsmu.RLock()
+	defer obsmu.RUnlock()
+	return defobserver
+}
+
+// WithObserver returns a copy of the API which reports its calls to o instead of the default Observer.
+// A nil Observer disables the events.
+func (a API) WithObserver(o Observer) API {
+	if o == nil {
+		o = nopObserver{}
+	}
+	a.client = a.client.withObserver(o)
+	return a
+}
+
+// obs returns the Observer of c, or the default one if c has none.
+func (c client) obs() Observer {
+	if c.observer != nil {
+		return c.observer
+	}
+	return defaultObserver()
+}
+
+// nopObserver discards all the events.
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(RequestEvent) {}
+func (nopObserver) ObserveUpdate(UpdateEvent)   {}
+
+// ExpvarObserver is an Observer which publishes the counters of the events with the expvar package.
+//
+// The published map contains:
+//   - requests, request_errors and request_seconds: the calls, failed calls and time spent, by method;
+//   - error_codes: the errors returned by Telegram, by error code;
+//   - rate_limited and wait_seconds: the calls failed with error 429 and the time spent in the rate limiters;
+//   - updates and update_seconds: the updates handled and the time spent handling them, by type;
+//   - sessions and panics: the sessions created and the panics in the Update methods.
+type ExpvarObserver struct {
+	*expvar.Map
+	requests    *expvar.Map
+	errors      *expvar.Map
+	reqSeconds  *expvar.Map
+	errorCodes  *expvar.Map
+	updates     *expvar.Map
+	updSeconds  *expvar.Map
+	rateLimited *expvar.Int
+	sessions    *expvar.Int
+	panics      *expvar.Int
+	waitSeconds *expvar.Float
+}
+
+// NewExpvarObserver returns a new ExpvarObserver publishing its counters under the given name.
+// Like expvar.Publish, it panics if the name is already in use.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	e := &ExpvarObserver{
+		Map:         expvar.NewMap(name),
+		requests:    new(expvar.Map).Init(),
+		errors:      new(expvar.Map).Init(),
+		reqSeconds:  new(expvar.Map).Init(),
+		errorCodes:  new(expvar.Map).Init(),
+		updates:     new(expvar.Map).Init(),
+		updSeconds:  new(expvar.Map).Init(),
+		rateLimited: new(expvar.Int),
+		sessions:    new(expvar.Int),
+		panics:      new(expvar.Int),
+		waitSeconds: new(expvar.Float),
+	}
+
+	e.Set("requests", e.requests)
+	e.Set("request_errors", e.errors)
+	e.Set("request_seconds", e.reqSeconds)
+	e.Set("error_codes", e.errorCodes)
+	e.Set("rate_limited", e.rateLimited)
+	e.Set("wait_seconds", e.waitSeconds)
+	e.Set("updates", e.updates)
+	e.Set("update_seconds", e.updSeconds)
+	e.Set("sessions", e.sessions)
+	e.Set("panics", e.panics)
+	return e
+}
+
+// ObserveRequest implements Observer.
+func (e *ExpvarObserver) ObserveRequest(ev RequestEvent) {
+	e.requests.Add(ev.Method, 1)
+	e.reqSeconds.AddFloat(ev.Method, ev.Duration.Seconds())
+	e.waitSeconds.Add(ev.Wait.Seconds())
+
+	if ev.Err != nil {
+		e.errors.Add(ev.Method, 1)
+	}
+	if ev.ErrorCode != 0 {
+		e.errorCodes.Add(itoa(int64(ev.ErrorCode)), 1)
+	}
+	if ev.ErrorCode == http.StatusTooManyRequests {
+		e.rateLimited.Add(1)
+	}
+}
+
+// ObserveUpdate implements Observer.
+func (e *ExpvarObserver) ObserveUpdate(ev UpdateEvent) {
+	e.updates.Add(string(ev.Type), 1)
+	e.updSeconds.AddFloat(string(ev.Type), ev.Duration.Seconds())
+
+	if ev.NewSession {
+		e.sessions.Add(1)
+	}
+	if ev.Panic != nil {
+		e.panics.Add(1)
+	}
+}
+
+// updateType returns the type of u.
+func updateType(u *Update) UpdateType {
+	switch {
+	case u.Message != nil:
+		return MessageUpdate
+	case u.EditedMessage != nil:
+		return EditedMessageUpdate
+	case u.ChannelPost != nil:
+		return ChannelPostUpdate
+	case u.EditedChannelPost != nil:
+		return EditedChannelPostUpdate
+	case u.BusinessConnection != nil:
+		return BusinessConnectionUpdate
+	case u.BusinessMessage != nil:
+		return BusinessMessageUpdate
+	case u.EditedBusinessMessage != nil:
+		return EditedBusinessMessageUpdate
+	case u.DeletedBusinessMessages != nil:
+		return DeletedBusinessMessagesUpdate
+	case u.MessageReaction != nil:
+		return MessageReactionUpdate
+	case u.MessageReactionCount != nil:
+		return MessageReactionCountUpdate
+	case u.InlineQuery != nil:
+		return InlineQueryUpdate
+	case u.ChosenInlineResult != nil:
+		return ChosenInlineResultUpdate
+	case u.CallbackQuery != nil:
+		return CallbackQueryUpdate
+	case u.ShippingQuery != nil:
+		return ShippingQueryUpdate
+	case u.PreCheckoutQuery != nil:
+		return PreCheckoutQueryUpdate
+	case u.Poll != nil:
+		return PollUpdate
+	case u.PollAnswer != nil:
+		return PollAnswerUpdate
+	case u.MyChatMember != nil:
+		return MyChatMemberUpdate
+	case u.ChatMember != nil:
+		return ChatMemberUpdate
+	case u.ChatJoinRequest != nil:
+		return ChatJoinRequestUpdate
+	case u.ChatBoost != nil:
+		return ChatBoostUpdate
+	case u.RemovedChatBoost != nil:
+		return RemovedChatBoostUpdate
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,125 @@
+package echosphere
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recObserver struct {
+	requests []RequestEvent
+	updates  []UpdateEvent
+	mu       sync.Mutex
+}
+
+func (r *recObserver) ObserveRequest(ev RequestEvent) {
+	r.mu.Lock()
+	r.requests = append(r.requests, ev)
+	r.mu.Unlock()
+}
+
+func (r *recObserver) ObserveUpdate(ev UpdateEvent) {
+	r.mu.Lock()
+	r.updates = append(r.updates, ev)
+	r.mu.Unlock()
+}
+
+type panicBot struct{}
+
+func (panicBot) Update(*Update) { panic("boom") }
+
+func TestObserverRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`)
+	}))
+	defer srv.Close()
+
+	var (
+		rec = &recObserver{}
+		api = NewLocalAPI(srv.URL, "123:token").WithObserver(rec)
+	)
+
+	if _, err := api.SendMessage("text", 1060, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(rec.requests) != 1 {
+		t.Fatalf("expected 1 event, got %+v", rec.requests)
+	}
+
+	ev := rec.requests[0]
+	if ev.Method != "sendMessage" || ev.ChatID != "1060" || ev.StatusCode != http.StatusTooManyRequests || ev.ErrorCode != 429 || ev.Err == nil {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev.Duration < ev.Wait {
+		t.Fatalf("expected the duration to include the wait, got %+v", ev)
+	}
+}
+
+func TestObserverUpdate(t *testing.T) {
+	var (
+		rec = &recObserver{}
+		d   = &Dispatcher{api: NewAPI("token").WithObserver(rec)}
+		u   = &Update{ID: 7, CallbackQuery: &CallbackQuery{Message: &Message{Chat: Chat{ID: 1061}}}}
+	)
+
+	d.run(test{}, u, 1061, true)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("expected the panic to be propagated, got %v", r)
+			}
+		}()
+		d.run(panicBot{}, u, 1061, false)
+	}()
+
+	if len(rec.updates) != 2 {
+		t.Fatalf("expected 2 events, got %+v", rec.updates)
+	}
+
+	if ev := rec.updates[0]; ev.Type != CallbackQueryUpdate || ev.ChatID != 1061 || ev.UpdateID != 7 || !ev.NewSession || ev.Panic != nil {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev := rec.updates[1]; ev.NewSession || ev.Panic != "boom" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+// expvarRuns makes the name of the ExpvarObserver unique across the runs of the test,
+// since the expvar variables can't be unpublished.
+var expvarRuns int
+
+func TestExpvarObserver(t *testing.T) {
+	expvarRuns++
+	name := fmt.Sprintf("echosphere_test_%d", expvarRuns)
+	o := NewExpvarObserver(name)
+
+	o.ObserveRequest(RequestEvent{Method: "sendMessage"})
+	o.ObserveRequest(RequestEvent{Method: "sendMessage", ErrorCode: 429, Err: &APIError{code: 429}})
+	o.ObserveUpdate(UpdateEvent{Type: MessageUpdate, NewSession: true})
+	o.ObserveUpdate(UpdateEvent{Type: MessageUpdate, Panic: "boom"})
+
+	counters := map[string]string{
+		"requests":       `{"sendMessage": 2}`,
+		"request_errors": `{"sendMessage": 1}`,
+		"error_codes":    `{"429": 1}`,
+		"rate_limited":   "1",
+		"updates":        `{"message": 2}`,
+		"sessions":       "1",
+		"panics":         "1",
+	}
+	for k, v := range counters {
+		if got := o.Get(k).String(); got != v {
+			t.Fatalf("%s: expected %s, got %s", k, v, got)
+		}
+	}
+
+	if expvar.Get(name) == nil {
+		t.Fatal("expected the observer to be published")
+	}
+}
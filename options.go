@@ -18,6 +18,8 @@
 
 package echosphere
 
+import "io"
+
 // ParseMode is a custom type for the various frequent options used by some methods of the API.
 type ParseMode string
 
@@ -91,6 +93,7 @@ const (
 	TextLinkEntity                        = "text_link"
 	TextMentionEntity                     = "text_mention"
 	CustomEmojiEntity                     = "custom_emoji"
+	BlockquoteEntity                      = "blockquote"
 )
 
 // UpdateType is a custom type for the various update types that a bot can be subscribed to.
@@ -98,19 +101,28 @@ type UpdateType string
 
 // These are all the possible types that a bot can be subscribed to.
 const (
-	MessageUpdate            UpdateType = "message"
-	EditedMessageUpdate                 = "edited_message"
-	ChannelPostUpdate                   = "channel_post"
-	EditedChannelPostUpdate             = "edited_channel_post"
-	InlineQueryUpdate                   = "inline_query"
-	ChosenInlineResultUpdate            = "chosen_inline_result"
-	CallbackQueryUpdate                 = "callback_query"
-	ShippingQueryUpdate                 = "shipping_query"
-	PreCheckoutQueryUpdate              = "pre_checkout_query"
-	PollUpdate                          = "poll"
-	PollAnswerUpdate                    = "poll_answer"
-	MyChatMemberUpdate                  = "my_chat_member"
-	ChatMemberUpdate                    = "chat_member"
+	MessageUpdate                 UpdateType = "message"
+	EditedMessageUpdate                      = "edited_message"
+	ChannelPostUpdate                        = "channel_post"
+	EditedChannelPostUpdate                  = "edited_channel_post"
+	InlineQueryUpdate                        = "inline_query"
+	ChosenInlineResultUpdate                 = "chosen_inline_result"
+	CallbackQueryUpdate                      = "callback_query"
+	ShippingQueryUpdate                      = "shipping_query"
+	PreCheckoutQueryUpdate                   = "pre_checkout_query"
+	PollUpdate                               = "poll"
+	PollAnswerUpdate                         = "poll_answer"
+	MyChatMemberUpdate                       = "my_chat_member"
+	ChatMemberUpdate                         = "chat_member"
+	ChatJoinRequestUpdate                    = "chat_join_request"
+	ChatBoostUpdate                          = "chat_boost"
+	RemovedChatBoostUpdate                   = "removed_chat_boost"
+	MessageReactionUpdate                    = "message_reaction"
+	MessageReactionCountUpdate               = "message_reaction_count"
+	BusinessConnectionUpdate                 = "business_connection"
+	BusinessMessageUpdate                    = "business_message"
+	EditedBusinessMessageUpdate              = "edited_business_message"
+	DeletedBusinessMessagesUpdate            = "deleted_business_messages"
 )
 
 // ReplyMarkup is an interface for the various keyboard types.
@@ -305,12 +317,19 @@ type StickerOptions struct {
 
 // InputFile is a struct which contains data about a file to be sent.
 type InputFile struct {
-	id      string
-	path    string
-	url     string
-	content []byte
+	reader   io.Reader
+	progress ProgressFunc
+	id       string
+	path     string
+	url      string
+	content  []byte
+	size     int64
 }
 
+// ProgressFunc is called while a file is being uploaded with the number of bytes sent so far
+// and the total size of the file, which is -1 if unknown.
+type ProgressFunc func(sent, total int64)
+
 // NewInputFileID is a wrapper for InputFile which only fills the id field.
 func NewInputFileID(ID string) InputFile {
 	return InputFile{id: ID}
@@ -331,6 +350,21 @@ func NewInputFileBytes(fileName string, content []byte) InputFile {
 	return InputFile{path: fileName, content: content}
 }
 
+// NewInputFileReader is a wrapper for InputFile which streams the file named fileName from r
+// instead of loading it in memory.
+// The size is the length in bytes of the content, or -1 if unknown, in which case
+// the upload is sent with the chunked transfer encoding.
+// Since r is consumed by the upload, the resulting InputFile can be used only once.
+func NewInputFileReader(fileName string, r io.Reader, size int64) InputFile {
+	return InputFile{path: fileName, reader: r, size: size}
+}
+
+// WithProgress returns a copy of the InputFile which calls fn while being uploaded.
+func (i InputFile) WithProgress(fn ProgressFunc) InputFile {
+	i.progress = fn
+	return i
+}
+
 // PhotoOptions contains the optional parameters used by the SendPhoto method.
 type PhotoOptions struct {
 	ReplyMarkup          ReplyMarkup     `query:"reply_markup"`
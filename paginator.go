@@ -0,0 +1,225 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// PageSource returns the buttons of the items in the range [offset, offset+limit)
+// along with the total number of items available.
+type PageSource func(offset, limit int) (items []InlineKeyboardButton, total int, err error)
+
+// Paginator is an inline keyboard widget which shows a list of items split in pages
+// with the navigation buttons to move between them.
+// The navigation buttons send callback queries whose data is prefixed by the paginator's name,
+// so that several paginators can coexist in the same bot.
+type Paginator struct {
+	source   PageSource
+	name     string
+	api      API
+	pageSize int
+	columns  int
+	window   int
+}
+
+// NewPaginator returns a new Paginator named name, which is used as the namespace
+// of the callback data, showing pageSize items per page taken from source.
+func NewPaginator(api API, name string, pageSize int, source PageSource) *Paginator {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	return &Paginator{
+		api:      api,
+		name:     name,
+		pageSize: pageSize,
+		source:   source,
+		columns:  1,
+		window:   5,
+	}
+}
+
+// Columns sets the number of item buttons per row, 1 by default.
+func (p *Paginator) Columns(n int) *Paginator {
+	p.columns = n
+	return p
+}
+
+// Window sets how many page number buttons are shown in the navigation row, 5 by default.
+// It's clamped between 1 and 6, so that the row fits the arrows within MaxInlineButtonsPerRow.
+func (p *Paginator) Window(n int) *Paginator {
+	switch limit := MaxInlineButtonsPerRow - 2; {
+	case n < 1:
+		n = 1
+	case n > limit:
+		n = limit
+	}
+	p.window = n
+	return p
+}
+
+// Keyboard returns the inline keyboard showing the given page, starting from 0.
+// Out of range pages are clamped to the first or last one.
+func (p *Paginator) Keyboard(page int) (InlineKeyboardMarkup, error) {
+	if page < 0 {
+		page = 0
+	}
+
+	items, total, err := p.source(page*p.pageSize, p.pageSize)
+	if err != nil {
+		return InlineKeyboardMarkup{}, err
+	}
+
+	// Fetch the last page when the requested one is past the end.
+	if pages := p.pages(total); page >= pages && pages > 0 {
+		page = pages - 1
+		if items, total, err = p.source(page*p.pageSize, p.pageSize); err != nil {
+			return InlineKeyboardMarkup{}, err
+		}
+	}
+
+	kbd := NewInlineKeyboard().Columns(p.columns).Add(items...)
+	if nav := p.navigation(page, p.pages(total)); len(nav) > 0 {
+		kbd.Columns(0).Row(nav...)
+	}
+	return kbd.Build()
+}
+
+// Send sends a message with the given text and the first page of the paginator.
+// Any reply markup in opts is replaced by the paginator's keyboard.
+func (p *Paginator) Send(text string, chatID int64, opts *MessageOptions) (res APIResponseMessage, err error) {
+	var o MessageOptions
+
+	kbd, err := p.Keyboard(0)
+	if err != nil {
+		return res, err
+	}
+
+	if opts != nil {
+		o = *opts
+	}
+	o.ReplyMarkup = kbd
+	return p.api.SendMessage(text, chatID, &o)
+}
+
+// HandleCallback handles the callback queries sent by the navigation buttons of the paginator,
+// editing the keyboard of the message, either a normal or an inline one, to show the requested page.
+// It reports whether the callback query belonged to the paginator, in which case it's also answered.
+func (p *Paginator) HandleCallback(q *CallbackQuery) (bool, error) {
+	if q == nil {
+		return false, nil
+	}
+
+	data, ok := cutPrefix(q.Data, p.prefix())
+	if !ok {
+		return false, nil
+	}
+
+	err := p.showPage(q, data)
+	// A double tap on a button edits the keyboard to the same page.
+	if errors.Is(err, ErrMessageNotModified) {
+		err = nil
+	}
+
+	// The callback query is answered even on failure, to stop the loading animation of the button.
+	if _, answerErr := p.api.AnswerCallbackQuery(q.ID, nil); err == nil {
+		err = answerErr
+	}
+	return true, err
+}
+
+// showPage edits the keyboard of the message of q to show the page in data.
+func (p *Paginator) showPage(q *CallbackQuery, data string) error {
+	// The button of the current page doesn't need any edit.
+	if data == "" {
+		return nil
+	}
+
+	page, err := strconv.Atoi(data)
+	if err != nil {
+		return fmt.Errorf("invalid paginator callback data %q", q.Data)
+	}
+
+	kbd, err := p.Keyboard(page)
+	if err != nil {
+		return err
+	}
+
+	var msg MessageIDOptions
+	switch {
+	case q.InlineMessageID != "":
+		msg = NewInlineMessageID(q.InlineMessageID)
+	case q.Message != nil:
+		msg = NewMessageID(q.Message.Chat.ID, q.Message.ID)
+	default:
+		return fmt.Errorf("callback query %s has no message to edit", q.ID)
+	}
+
+	_, err = p.api.EditMessageReplyMarkup(msg, &MessageReplyMarkup{ReplyMarkup: kbd})
+	return err
+}
+
+func (p *Paginator) prefix() string {
+	return p.name + ":"
+}
+
+func (p *Paginator) pages(total int) int {
+	return (total + p.pageSize - 1) / p.pageSize
+}
+
+// navigation returns the navigation buttons for the given page.
+func (p *Paginator) navigation(page, pages int) (nav []InlineKeyboardButton) {
+	if pages <= 1 {
+		return nil
+	}
+
+	start := page - p.window/2
+	if start+p.window > pages {
+		start = pages - p.window
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + p.window
+	if end > pages {
+		end = pages
+	}
+
+	if page > 0 {
+		nav = append(nav, p.button("‹", page-1))
+	}
+	for i := start; i < end; i++ {
+		if i == page {
+			nav = append(nav, NewInlineButtonCallback(fmt.Sprintf("· %d ·", i+1), p.prefix()))
+			continue
+		}
+		nav = append(nav, p.button(strconv.Itoa(i+1), i))
+	}
+	if page < pages-1 {
+		nav = append(nav, p.button("›", page+1))
+	}
+	return
+}
+
+func (p *Paginator) button(text string, page int) InlineKeyboardButton {
+	return NewInlineButtonCallback(text, p.prefix()+strconv.Itoa(page))
+}
@@ -0,0 +1,123 @@
+package echosphere
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testPageSource(total int) PageSource {
+	return func(offset, limit int) (items []InlineKeyboardButton, n int, err error) {
+		for i := offset; i < offset+limit && i < total; i++ {
+			items = append(items, NewInlineButtonCallback(strconv.Itoa(i), "item:"+strconv.Itoa(i)))
+		}
+		return items, total, nil
+	}
+}
+
+func TestPaginatorKeyboard(t *testing.T) {
+	p := NewPaginator(api, "pg", 2, testPageSource(5)).Columns(2)
+
+	kbd, err := p.Keyboard(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := InlineKeyboardMarkup{
+		InlineKeyboard: [][]InlineKeyboardButton{
+			{
+				{Text: "2", CallbackData: "item:2"},
+				{Text: "3", CallbackData: "item:3"},
+			},
+			{
+				{Text: "‹", CallbackData: "pg:0"},
+				{Text: "1", CallbackData: "pg:0"},
+				{Text: "· 2 ·", CallbackData: "pg:"},
+				{Text: "3", CallbackData: "pg:2"},
+				{Text: "›", CallbackData: "pg:2"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(kbd, expected) {
+		t.Logf("expected keyboard: %+v", expected)
+		t.Logf("got keyboard: %+v", kbd)
+		t.Fatal("error: keyboard mismatch")
+	}
+}
+
+func TestPaginatorKeyboardClamp(t *testing.T) {
+	p := NewPaginator(api, "pg", 2, testPageSource(3))
+
+	kbd, err := p.Keyboard(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rows := kbd.InlineKeyboard; len(rows) != 2 || rows[0][0].CallbackData != "item:2" {
+		t.Fatalf("expected the last page, got %+v", kbd)
+	}
+}
+
+func TestPaginatorHandleCallbackForeign(t *testing.T) {
+	p := NewPaginator(api, "pg", 2, testPageSource(3))
+
+	if ok, err := p.HandleCallback(&CallbackQuery{Data: "other:1"}); ok || err != nil {
+		t.Fatalf("unexpected result for foreign callback data: %t %v", ok, err)
+	}
+}
+
+func TestPaginatorWindow(t *testing.T) {
+	p := NewPaginator(api, "pg", 1, testPageSource(20)).Window(10)
+
+	kbd, err := p.Keyboard(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nav := kbd.InlineKeyboard[len(kbd.InlineKeyboard)-1]; len(nav) != MaxInlineButtonsPerRow {
+		t.Fatalf("unexpected navigation row %+v", nav)
+	}
+}
+
+func TestPaginatorHandleCallbackAnswer(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		methods []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		methods = append(methods, r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:])
+		switch chatID := r.FormValue("chat_id"); {
+		case strings.HasSuffix(r.URL.Path, "/answerCallbackQuery"):
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		case chatID == "1110":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: message is not modified"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := NewPaginator(NewLocalAPI(srv.URL, "123:token"), "pg", 2, testPageSource(10))
+
+	for chatID, fails := range map[int64]bool{1110: false, 1111: true} {
+		methods = nil
+		q := &CallbackQuery{ID: "q", Data: "pg:1", Message: &Message{ID: 1, Chat: Chat{ID: chatID}}}
+
+		if ok, err := p.HandleCallback(q); !ok || (err != nil) != fails {
+			t.Fatalf("chat %d: unexpected result %t %v", chatID, ok, err)
+		}
+		if !reflect.DeepEqual(methods, []string{"editMessageReplyMarkup", "answerCallbackQuery"}) {
+			t.Fatalf("chat %d: unexpected calls %v", chatID, methods)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// These are the errors returned when the Telegram Passport data can't be decrypted.
+var (
+	ErrPassportHash    = errors.New("the hash of the decrypted passport data doesn't match")
+	ErrPassportPadding = errors.New("invalid padding in the decrypted passport data")
+)
+
+// PassportCredentials are the decrypted EncryptedCredentials, containing the secrets
+// needed to decrypt the data and the files of each element.
+type PassportCredentials struct {
+	SecureData map[EncryptedPassportElementType]SecureValue `json:"secure_data"`
+	Nonce      string                                       `json:"nonce"`
+}
+
+// SecureValue contains the credentials required to decrypt the data and the files of an element.
+type SecureValue struct {
+	Data        *DataCredentials  `json:"data,omitempty"`
+	FrontSide   *FileCredentials  `json:"front_side,omitempty"`
+	ReverseSide *FileCredentials  `json:"reverse_side,omitempty"`
+	Selfie      *FileCredentials  `json:"selfie,omitempty"`
+	Translation []FileCredentials `json:"translation,omitempty"`
+	Files       []FileCredentials `json:"files,omitempty"`
+}
+
+// DataCredentials contain the secret and the hash needed to decrypt the data field of an element.
+type DataCredentials struct {
+	DataHash string `json:"data_hash"`
+	Secret   string `json:"secret"`
+}
+
+// FileCredentials contain the secret and the hash needed to decrypt a PassportFile.
+type FileCredentials struct {
+	FileHash string `json:"file_hash"`
+	Secret   string `json:"secret"`
+}
+
+// PersonalDetails represents the personal details of the user.
+type PersonalDetails struct {
+	FirstName            string `json:"first_name"`
+	LastName             string `json:"last_name"`
+	MiddleName           string `json:"middle_name,omitempty"`
+	BirthDate            string `json:"birth_date"`
+	Gender               string `json:"gender"`
+	CountryCode          string `json:"country_code"`
+	ResidenceCountryCode string `json:"residence_country_code"`
+	FirstNameNative      string `json:"first_name_native,omitempty"`
+	LastNameNative       string `json:"last_name_native,omitempty"`
+	MiddleNameNative     string `json:"middle_name_native,omitempty"`
+}
+
+// ResidentialAddress represents the residential address of the user.
+type ResidentialAddress struct {
+	StreetLine1 string `json:"street_line1"`
+	StreetLine2 string `json:"street_line2,omitempty"`
+	City        string `json:"city"`
+	State       string `json:"state,omitempty"`
+	CountryCode string `json:"country_code"`
+	PostCode    string `json:"post_code"`
+}
+
+// IDDocumentData represents the data of an identity document.
+type IDDocumentData struct {
+	DocumentNo string `json:"document_no"`
+	ExpiryDate string `json:"expiry_date,omitempty"`
+}
+
+// DecryptedPassportFile is a PassportFile along with the credentials to decrypt its content,
+// once downloaded with GetFile and DownloadFile.
+type DecryptedPassportFile struct {
+	PassportFile
+	Credentials FileCredentials
+}
+
+// Decrypt decrypts the content of the file, checking its hash.
+func (f DecryptedPassportFile) Decrypt(data []byte) ([]byte, error) {
+	return DecryptPassportFile(data, f.Credentials)
+}
+
+// DecryptedPassportElement is an EncryptedPassportElement whose data has been decrypted,
+// according to its type, into PersonalDetails, Document or Address.
+// The files are decrypted separately once downloaded, with their Decrypt method.
+type DecryptedPassportElement struct {
+	PersonalDetails *PersonalDetails
+	Document        *IDDocumentData
+	Address         *ResidentialAddress
+	FrontSide       *DecryptedPassportFile
+	ReverseSide     *DecryptedPassportFile
+	Selfie          *DecryptedPassportFile
+	Files           []DecryptedPassportFile
+	Translation     []DecryptedPassportFile
+	Type            EncryptedPassportElementType
+	PhoneNumber     string
+	Email           string
+	Hash            string
+}
+
+// DecryptedPassport is the decrypted PassportData.
+type DecryptedPassport struct {
+	Credentials PassportCredentials
+	Elements    []DecryptedPassportElement
+}
+
+// PassportDecryptor decrypts the Telegram Passport data shared with the bot,
+// with the private key whose public counterpart has been set with BotFather.
+type PassportDecryptor struct {
+	key *rsa.PrivateKey
+}
+
+// NewPassportDecryptor returns a new PassportDecryptor using the given private key.
+func NewPassportDecryptor(key *rsa.PrivateKey) PassportDecryptor {
+	return PassportDecryptor{key: key}
+}
+
+// PublicKey returns the PEM encoded public key of d, to be set with BotFather and sent in the PassportRequest.
+func (d PassportDecryptor) PublicKey() string {
+	der, err := x509.MarshalPKIXPublicKey(&d.key.PublicKey)
+	if err != nil {
+		return ""
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// ParsePassportKey parses a PEM encoded RSA private key, in PKCS #1 or PKCS #8 form.
+func ParsePassportKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key of type %T", key)
+	}
+	return rsaKey, nil
+}
+
+// Decrypt decrypts the credentials and the data of all the elements in p.
+func (d PassportDecryptor) Decrypt(p PassportData) (*DecryptedPassport, error) {
+	creds, err := d.DecryptCredentials(p.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &DecryptedPassport{
+		Credentials: *creds,
+		Elements:    make([]DecryptedPassportElement, 0, len(p.Data)),
+	}
+	for _, e := range p.Data {
+		el, err := decryptElement(e, creds.SecureData[e.Type])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Type, err)
+		}
+		ret.Elements = append(ret.Elements, el)
+	}
+	return ret, nil
+}
+
+// DecryptCredentials decrypts the secret of c with the private key, then the credentials with the secret.
+func (d PassportDecryptor) DecryptCredentials(c EncryptedCredentials) (*PassportCredentials, error) {
+	var creds PassportCredentials
+
+	encSecret, err := base64.StdEncoding.DecodeString(c.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials secret: %w", err)
+	}
+
+	secret, err := rsa.DecryptOAEP(sha1.New(), nil, d.key, encSecret, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt the credentials secret: %w", err)
+	}
+
+	data, err := decryptPassportValue(c.Data, c.Hash, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &creds, json.Unmarshal(data, &creds)
+}
+
+// DecryptPassportData decrypts the data field of an EncryptedPassportElement with the given credentials
+// and unmarshals it into v, e.g. a *PersonalDetails, *IDDocumentData or *ResidentialAddress.
+func DecryptPassportData(data string, creds DataCredentials, v any) error {
+	secret, err := base64.StdEncoding.DecodeString(creds.Secret)
+	if err != nil {
+		return fmt.Errorf("invalid data secret: %w", err)
+	}
+
+	plain, err := decryptPassportValue(data, creds.DataHash, secret)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// DecryptPassportFile decrypts the content of a PassportFile, as returned by DownloadFile, with the given credentials.
+func DecryptPassportFile(data []byte, creds FileCredentials) ([]byte, error) {
+	secret, err := base64.StdEncoding.DecodeString(creds.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file secret: %w", err)
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(creds.FileHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file hash: %w", err)
+	}
+	return decryptPassport(data, hash, secret)
+}
+
+// decryptElement decrypts the data of e and pairs its files with their credentials.
+func decryptElement(e EncryptedPassportElement, creds SecureValue) (ret DecryptedPassportElement, err error) {
+	ret = DecryptedPassportElement{
+		Type:        e.Type,
+		PhoneNumber: e.PhoneNumber,
+		Email:       e.Email,
+		Hash:        e.Hash,
+	}
+
+	if e.Data != "" {
+		if creds.Data == nil {
+			return ret, errors.New("missing data credentials")
+		}
+
+		switch e.Type {
+		case TypePersonalDetails:
+			ret.PersonalDetails = new(PersonalDetails)
+			err = DecryptPassportData(e.Data, *creds.Data, ret.PersonalDetails)
+		case TypePassport, TypeDriverLicense, TypeIdentityCard, TypeInternalPassport:
+			ret.Document = new(IDDocumentData)
+			err = DecryptPassportData(e.Data, *creds.Data, ret.Document)
+		case TypeAddress:
+			ret.Address = new(ResidentialAddress)
+			err = DecryptPassportData(e.Data, *creds.Data, ret.Address)
+		}
+		if err != nil {
+			return ret, err
+		}
+	}
+
+	if ret.FrontSide, err = passportFile(e.FrontSide, creds.FrontSide); err != nil {
+		return
+	}
+	if ret.ReverseSide, err = passportFile(e.ReverseSide, creds.ReverseSide); err != nil {
+		return
+	}
+	if ret.Selfie, err = passportFile(e.Selfie, creds.Selfie); err != nil {
+		return
+	}
+	if ret.Files, err = passportFiles(e.Files, creds.Files); err != nil {
+		return
+	}
+	ret.Translation, err = passportFiles(e.Translation, creds.Translation)
+	return
+}
+
+func passportFile(f *PassportFile, creds *FileCredentials) (*DecryptedPassportFile, error) {
+	if f == nil {
+		return nil, nil
+	}
+	if creds == nil {
+		return nil, fmt.Errorf("missing credentials for file %s", f.FileID)
+	}
+	return &DecryptedPassportFile{PassportFile: *f, Credentials: *creds}, nil
+}
+
+func passportFiles(files *[]PassportFile, creds []FileCredentials) ([]DecryptedPassportFile, error) {
+	if files == nil {
+		return nil, nil
+	}
+	if len(*files) != len(creds) {
+		return nil, fmt.Errorf("got credentials for %d files out of %d", len(creds), len(*files))
+	}
+
+	ret := make([]DecryptedPassportFile, len(*files))
+	for i, f := range *files {
+		ret[i] = DecryptedPassportFile{PassportFile: f, Credentials: creds[i]}
+	}
+	return ret, nil
+}
+
+// decryptPassportValue decrypts the base64 encoded data with the base64 encoded hash and the given secret.
+func decryptPassportValue(data, hash string, secret []byte) ([]byte, error) {
+	enc, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted data: %w", err)
+	}
+
+	h, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data hash: %w", err)
+	}
+	return decryptPassport(enc, h, secret)
+}
+
+// decryptPassport decrypts data with AES-256-CBC, whose key and IV are derived from
+// the SHA-512 of secret and hash, then checks that the SHA-256 of the result matches hash
+// and removes the padding, whose length is given by the first byte.
+func decryptPassport(data, hash, secret []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("the length of the encrypted data (%d) isn't a multiple of the block size", len(data))
+	}
+
+	digest := sha512.Sum512(append(append([]byte{}, secret...), hash...))
+	block, err := aes.NewCipher(digest[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, digest[32:48]).CryptBlocks(plain, data)
+
+	if sum := sha256.Sum256(plain); subtle.ConstantTimeCompare(sum[:], hash) != 1 {
+		return nil, ErrPassportHash
+	}
+
+	pad := int(plain[0])
+	if pad < 32 || pad > len(plain) {
+		return nil, ErrPassportPadding
+	}
+	return plain[pad:], nil
+}
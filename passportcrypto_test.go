@@ -0,0 +1,102 @@
+package echosphere
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The vectors in assets/tests/passport.json have been encrypted with OpenSSL
+// following the Telegram Passport documentation, with the test key they contain.
+type passportVectors struct {
+	Key          string       `json:"key"`
+	PassportData PassportData `json:"passport_data"`
+	FrontSide    string       `json:"front_side"`
+}
+
+func loadPassportVectors(t *testing.T) (passportVectors, PassportDecryptor) {
+	var v passportVectors
+
+	b, err := os.ReadFile("assets/tests/passport.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParsePassportKey([]byte(v.Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, NewPassportDecryptor(key)
+}
+
+func TestPassportDecrypt(t *testing.T) {
+	v, d := loadPassportVectors(t)
+
+	p, err := d.Decrypt(v.PassportData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Credentials.Nonce != "nonce-42" || len(p.Elements) != 3 {
+		t.Fatalf("unexpected passport %+v", p)
+	}
+
+	if pd := p.Elements[0].PersonalDetails; pd == nil || pd.FirstName != "Mario" || pd.BirthDate != "01.02.1990" {
+		t.Fatalf("unexpected personal details %+v", pd)
+	}
+
+	passport := p.Elements[1]
+	if passport.Document == nil || passport.Document.DocumentNo != "AB1234567" || passport.FrontSide == nil {
+		t.Fatalf("unexpected passport element %+v", passport)
+	}
+
+	enc, _ := base64.StdEncoding.DecodeString(v.FrontSide)
+	front, err := passport.FrontSide.Decrypt(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(front) != "\xff\xd8\xff\xe0 not really a JPEG" {
+		t.Fatalf("unexpected file content %q", front)
+	}
+
+	if p.Elements[2].Email != "mario@example.com" {
+		t.Fatalf("unexpected email element %+v", p.Elements[2])
+	}
+}
+
+func TestPassportDecryptTampered(t *testing.T) {
+	v, d := loadPassportVectors(t)
+
+	enc, _ := base64.StdEncoding.DecodeString(v.FrontSide)
+	enc[len(enc)-1] ^= 1
+	if _, err := DecryptPassportFile(enc, FileCredentials{}); err == nil {
+		t.Fatal("expected an error without credentials")
+	}
+
+	creds, err := d.DecryptCredentials(v.PassportData.Credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptPassportFile(enc, *creds.SecureData[TypePassport].FrontSide); !errors.Is(err, ErrPassportHash) {
+		t.Fatalf("expected ErrPassportHash, got %v", err)
+	}
+
+	v.PassportData.Credentials.Hash = creds.SecureData[TypePersonalDetails].Data.DataHash
+	if _, err := d.Decrypt(v.PassportData); !errors.Is(err, ErrPassportHash) {
+		t.Fatalf("expected ErrPassportHash, got %v", err)
+	}
+}
+
+func TestPassportPublicKey(t *testing.T) {
+	_, d := loadPassportVectors(t)
+
+	if k := d.PublicKey(); !strings.HasPrefix(k, "-----BEGIN PUBLIC KEY-----") {
+		t.Fatalf("unexpected public key %q", k)
+	}
+}
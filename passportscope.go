@@ -0,0 +1,318 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PassportScope describes the Telegram Passport elements requested by the bot.
+type PassportScope struct {
+	Data []PassportScopeElement `json:"data"`
+	V    int                    `json:"v"`
+}
+
+// PassportScopeElement is an interface for the various PassportScopeElement types.
+type PassportScopeElement interface {
+	ImplementsPassportScopeElement()
+}
+
+// PassportScopeElementOne represents a requested element.
+type PassportScopeElementOne struct {
+	Type        EncryptedPassportElementType `json:"type"`
+	Selfie      bool                         `json:"selfie,omitempty"`
+	Translation bool                         `json:"translation,omitempty"`
+	NativeNames bool                         `json:"native_names,omitempty"`
+}
+
+// ImplementsPassportScopeElement is a dummy method which exists to implement the interface PassportScopeElement.
+func (p PassportScopeElementOne) ImplementsPassportScopeElement() {}
+
+// PassportScopeElementOneOf represents several elements one of which must be provided.
+type PassportScopeElementOneOf struct {
+	OneOf       []PassportScopeElementOne `json:"one_of"`
+	Selfie      bool                      `json:"selfie,omitempty"`
+	Translation bool                      `json:"translation,omitempty"`
+}
+
+// ImplementsPassportScopeElement is a dummy method which exists to implement the interface PassportScopeElement.
+func (p PassportScopeElementOneOf) ImplementsPassportScopeElement() {}
+
+// NewPassportScope returns a PassportScope requesting the given elements.
+func NewPassportScope(elements ...PassportScopeElement) PassportScope {
+	return PassportScope{Data: elements, V: 1}
+}
+
+// NewPassportScopeElement returns a PassportScopeElementOne requesting an element of type t.
+func NewPassportScopeElement(t EncryptedPassportElementType) PassportScopeElementOne {
+	return PassportScopeElementOne{Type: t}
+}
+
+// WithSelfie returns a copy of p which requests a selfie with the document.
+func (p PassportScopeElementOne) WithSelfie() PassportScopeElementOne {
+	p.Selfie = true
+	return p
+}
+
+// WithTranslation returns a copy of p which requests a certified English translation of the document.
+func (p PassportScopeElementOne) WithTranslation() PassportScopeElementOne {
+	p.Translation = true
+	return p
+}
+
+// WithNativeNames returns a copy of p which requests the names of the user
+// in the language of their country of residence, for TypePersonalDetails.
+func (p PassportScopeElementOne) WithNativeNames() PassportScopeElementOne {
+	p.NativeNames = true
+	return p
+}
+
+// NewPassportScopeOneOf returns a PassportScopeElementOneOf requesting one of the given elements.
+func NewPassportScopeOneOf(elements ...PassportScopeElementOne) PassportScopeElementOneOf {
+	return PassportScopeElementOneOf{OneOf: elements}
+}
+
+// WithSelfie returns a copy of p which requests a selfie with the chosen document.
+func (p PassportScopeElementOneOf) WithSelfie() PassportScopeElementOneOf {
+	p.Selfie = true
+	return p
+}
+
+// WithTranslation returns a copy of p which requests a certified English translation of the chosen document.
+func (p PassportScopeElementOneOf) WithTranslation() PassportScopeElementOneOf {
+	p.Translation = true
+	return p
+}
+
+// PassportRequest is a request of Telegram Passport data.
+// Marshaled to JSON, it's the options object of Telegram.Passport.auth in the JavaScript SDK.
+type PassportRequest struct {
+	Scope       PassportScope `json:"scope"`
+	PublicKey   string        `json:"public_key"`
+	Nonce       string        `json:"nonce"`
+	CallbackURL string        `json:"callback_url,omitempty"`
+	BotID       int64         `json:"bot_id"`
+}
+
+// NewPassportNonce returns a random nonce for a PassportRequest, which is then found
+// in the Nonce field of the decrypted PassportCredentials.
+func NewPassportNonce() (string, error) {
+	var b [16]byte
+
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// URL returns the tg:// link which opens the Telegram Passport authorization form.
+func (r PassportRequest) URL() string {
+	scope, _ := json.Marshal(r.Scope)
+
+	vals := url.Values{
+		"domain":     {"telegrampassport"},
+		"bot_id":     {itoa(r.BotID)},
+		"scope":      {string(scope)},
+		"public_key": {r.PublicKey},
+		"nonce":      {r.Nonce},
+	}
+	if r.CallbackURL != "" {
+		vals.Set("callback_url", r.CallbackURL)
+	}
+	return "tg://resolve?" + vals.Encode()
+}
+
+// Validate checks that the elements requested by s have been provided in p with all their fields and files,
+// returning the PassportElementError values for the incomplete ones, ready for SetPassportDataErrors.
+// The elements requested but not provided at all can't be reported to the user,
+// hence they're returned in the error.
+func (s PassportScope) Validate(p *DecryptedPassport) (errs []PassportElementError, err error) {
+	var (
+		missing  []string
+		elements = make(map[EncryptedPassportElementType]DecryptedPassportElement, len(p.Elements))
+	)
+
+	for _, e := range p.Elements {
+		elements[e.Type] = e
+	}
+
+	for _, se := range s.Data {
+		var req PassportScopeElementOne
+
+		switch se := se.(type) {
+		case PassportScopeElementOne:
+			req = se
+		case PassportScopeElementOneOf:
+			for _, o := range se.OneOf {
+				if _, ok := elements[o.Type]; ok {
+					req = o
+					req.Selfie = req.Selfie || se.Selfie
+					req.Translation = req.Translation || se.Translation
+					break
+				}
+			}
+		}
+
+		e, ok := elements[req.Type]
+		if !ok {
+			missing = append(missing, scopeElementName(se))
+			continue
+		}
+		errs = append(errs, validateElement(e, req, p.Credentials.SecureData[e.Type])...)
+	}
+
+	if len(missing) > 0 {
+		err = fmt.Errorf("missing passport elements: %s", strings.Join(missing, ", "))
+	}
+	return
+}
+
+// validateElement returns the errors for the fields and files requested by req which are missing in e.
+func validateElement(e DecryptedPassportElement, req PassportScopeElementOne, creds SecureValue) (errs []PassportElementError) {
+	var (
+		fields []string
+		parts  []string
+	)
+
+	switch e.Type {
+	case TypePersonalDetails:
+		var d PersonalDetails
+		if e.PersonalDetails != nil {
+			d = *e.PersonalDetails
+		}
+		fields = emptyFields(map[string]string{
+			"first_name":             d.FirstName,
+			"last_name":              d.LastName,
+			"birth_date":             d.BirthDate,
+			"gender":                 d.Gender,
+			"country_code":           d.CountryCode,
+			"residence_country_code": d.ResidenceCountryCode,
+		})
+		if req.NativeNames {
+			fields = append(fields, emptyFields(map[string]string{
+				"first_name_native": d.FirstNameNative,
+				"last_name_native":  d.LastNameNative,
+			})...)
+		}
+
+	case TypePassport, TypeDriverLicense, TypeIdentityCard, TypeInternalPassport:
+		var d IDDocumentData
+		if e.Document != nil {
+			d = *e.Document
+		}
+		fields = emptyFields(map[string]string{"document_no": d.DocumentNo})
+
+		if e.FrontSide == nil {
+			parts = append(parts, "front side")
+		}
+		if e.ReverseSide == nil && (e.Type == TypeDriverLicense || e.Type == TypeIdentityCard) {
+			parts = append(parts, "reverse side")
+		}
+		if e.Selfie == nil && req.Selfie {
+			parts = append(parts, "selfie")
+		}
+
+	case TypeAddress:
+		var d ResidentialAddress
+		if e.Address != nil {
+			d = *e.Address
+		}
+		fields = emptyFields(map[string]string{
+			"street_line1": d.StreetLine1,
+			"city":         d.City,
+			"country_code": d.CountryCode,
+			"post_code":    d.PostCode,
+		})
+
+	case TypeUtilityBill, TypeBankStatement, TypeRentalAgreement, TypePassportRegistration, TypeTemporaryRegistration:
+		if len(e.Files) == 0 {
+			parts = append(parts, "files")
+		}
+
+	case TypePhoneNumber:
+		if e.PhoneNumber == "" {
+			parts = append(parts, "phone number")
+		}
+
+	case TypeEmail:
+		if e.Email == "" {
+			parts = append(parts, "email")
+		}
+	}
+
+	if len(e.Translation) == 0 && req.Translation {
+		parts = append(parts, "translation")
+	}
+
+	for _, f := range fields {
+		var hash string
+		if creds.Data != nil {
+			hash = creds.Data.DataHash
+		}
+		errs = append(errs, PassportElementErrorDataField{
+			Source:    SourceData,
+			Type:      e.Type,
+			FieldName: f,
+			DataHash:  hash,
+			Message:   fmt.Sprintf("The field %s is required.", f),
+		})
+	}
+
+	if len(parts) > 0 {
+		errs = append(errs, PassportElementErrorUnspecified{
+			Source:      SourceUnspecified,
+			Type:        e.Type,
+			ElementHash: e.Hash,
+			Message:     fmt.Sprintf("Missing %s.", strings.Join(parts, ", ")),
+		})
+	}
+	return
+}
+
+// emptyFields returns the sorted names of the empty fields.
+func emptyFields(fields map[string]string) (ret []string) {
+	for name, value := range fields {
+		if value == "" {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return
+}
+
+func scopeElementName(se PassportScopeElement) string {
+	switch se := se.(type) {
+	case PassportScopeElementOne:
+		return string(se.Type)
+	case PassportScopeElementOneOf:
+		names := make([]string, len(se.OneOf))
+		for i, o := range se.OneOf {
+			names[i] = string(o.Type)
+		}
+		return "one of " + strings.Join(names, ", ")
+	default:
+		return fmt.Sprintf("%T", se)
+	}
+}
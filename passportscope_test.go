@@ -0,0 +1,91 @@
+package echosphere
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPassportRequestURL(t *testing.T) {
+	nonce, err := NewPassportNonce()
+	if err != nil || len(nonce) != 32 {
+		t.Fatalf("unexpected nonce %q, error %v", nonce, err)
+	}
+
+	req := PassportRequest{
+		BotID:     123,
+		PublicKey: "-----BEGIN PUBLIC KEY-----\n...",
+		Nonce:     nonce,
+		Scope: NewPassportScope(
+			NewPassportScopeElement(TypePersonalDetails).WithNativeNames(),
+			NewPassportScopeOneOf(
+				NewPassportScopeElement(TypePassport),
+				NewPassportScopeElement(TypeIdentityCard),
+			).WithSelfie(),
+		),
+	}
+
+	u, err := url.Parse(req.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := u.Query()
+	if u.Scheme != "tg" || q.Get("domain") != "telegrampassport" || q.Get("bot_id") != "123" ||
+		q.Get("nonce") != nonce || q.Get("public_key") != req.PublicKey {
+		t.Fatalf("unexpected URL %s", u)
+	}
+
+	const scope = `{"data":[{"type":"personal_details","native_names":true},` +
+		`{"one_of":[{"type":"passport"},{"type":"identity_card"}],"selfie":true}],"v":1}`
+	if q.Get("scope") != scope {
+		t.Fatalf("unexpected scope %s", q.Get("scope"))
+	}
+
+	b, _ := json.Marshal(req)
+	if !strings.Contains(string(b), `"bot_id":123`) || strings.Contains(string(b), "callback_url") {
+		t.Fatalf("unexpected JSON %s", b)
+	}
+}
+
+func TestPassportScopeValidate(t *testing.T) {
+	v, d := loadPassportVectors(t)
+
+	p, err := d.Decrypt(v.PassportData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope := NewPassportScope(
+		NewPassportScopeElement(TypePersonalDetails).WithNativeNames(),
+		NewPassportScopeOneOf(
+			NewPassportScopeElement(TypeIdentityCard),
+			NewPassportScopeElement(TypePassport),
+		).WithSelfie(),
+		NewPassportScopeElement(TypeAddress),
+		NewPassportScopeElement(TypeEmail),
+	)
+
+	errs, err := scope.Validate(p)
+	if err == nil || !strings.Contains(err.Error(), "address") {
+		t.Fatalf("expected the address to be missing, got %v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("unexpected errors %+v", errs)
+	}
+
+	dataHash := p.Credentials.SecureData[TypePersonalDetails].Data.DataHash
+	for i, field := range []string{"first_name_native", "last_name_native"} {
+		if e, ok := errs[i].(PassportElementErrorDataField); !ok || e.FieldName != field || e.DataHash != dataHash {
+			t.Fatalf("unexpected error %+v", errs[i])
+		}
+	}
+	if e, ok := errs[2].(PassportElementErrorUnspecified); !ok || e.Type != TypePassport || e.ElementHash != "h2" || !strings.Contains(e.Message, "selfie") {
+		t.Fatalf("unexpected error %+v", errs[2])
+	}
+
+	if errs, err := NewPassportScope(NewPassportScopeElement(TypePersonalDetails)).Validate(p); len(errs) != 0 || err != nil {
+		t.Fatalf("unexpected errors %+v, %v", errs, err)
+	}
+}
@@ -0,0 +1,441 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrJobNotFound is returned when cancelling a job which doesn't exist.
+var ErrJobNotFound = errors.New("job not found")
+
+// errCaptured is returned by the interceptor which captures the calls for NewAction.
+var errCaptured = errors.New("call captured")
+
+// Action is a call to a method of the Telegram Bot API, stored as the name of the method
+// and its parameters so that it can be persisted and performed later.
+type Action struct {
+	Params url.Values `json:"params"`
+	Method string     `json:"method"`
+}
+
+// NewAction returns the Action performing the call made by fn with the given API,
+// which is captured instead of being sent, e.g.:
+//
+//	action, err := echosphere.NewAction(func(api echosphere.API) error {
+//		_, err := api.SendMessage("Reminder!", chatID, nil)
+//		return err
+//	})
+//
+// Only the calls without files, or with files sent by file_id or URL, can be captured.
+func NewAction(fn func(api API) error) (Action, error) {
+	var action Action
+
+	api := NewAPI("").WithInterceptors(func(call *Call, _ Invoker) error {
+		if len(call.Media) > 0 || len(call.Stickers) > 0 {
+			return fmt.Errorf("%s: the media and stickers can't be scheduled", call.Endpoint)
+		}
+
+		params := cloneValues(call.Params)
+
+		for field, f := range call.Files {
+			switch {
+			case f.id != "":
+				params.Set(field, f.id)
+			case f.url != "":
+				params.Set(field, f.url)
+			default:
+				return fmt.Errorf("%s: the uploads can't be scheduled, send the file by file_id or URL", call.Endpoint)
+			}
+		}
+		for k, v := range call.Form {
+			params[k] = append(params[k], v...)
+		}
+
+		action = Action{Method: call.Endpoint, Params: params}
+		return errCaptured
+	})
+
+	if err := fn(api); !errors.Is(err, errCaptured) {
+		if err == nil {
+			err = errors.New("no call made")
+		}
+		return action, err
+	}
+	return action, nil
+}
+
+// Job is an Action scheduled at a given time, optionally recurring.
+type Job struct {
+	Action Action `json:"action"`
+	// At is the time of the next run.
+	At time.Time `json:"at"`
+	// ID identifies the job.
+	ID string `json:"id"`
+	// Every is the interval between the runs of a recurring job, 0 for a job run only once.
+	Every time.Duration `json:"every,omitempty"`
+}
+
+// JobStore persists the jobs of a Scheduler.
+type JobStore interface {
+	// Save adds the job or replaces the one with the same ID.
+	Save(job Job) error
+	// Delete removes the job with the given ID, reporting whether it existed.
+	Delete(id string) (bool, error)
+	// Get returns the job with the given ID, reporting whether it exists.
+	Get(id string) (Job, bool, error)
+	// List returns all the jobs.
+	List() ([]Job, error)
+}
+
+// memoryJobStore is a JobStore keeping the jobs in memory.
+type memoryJobStore struct {
+	jobs map[string]Job
+	mu   sync.Mutex
+}
+
+// NewMemoryJobStore returns a JobStore which keeps the jobs in memory,
+// hence they don't survive restarts.
+func NewMemoryJobStore() JobStore {
+	return &memoryJobStore{jobs: make(map[string]Job)}
+}
+
+// Save implements JobStore.
+func (m *memoryJobStore) Save(job Job) error {
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+	return nil
+}
+
+// Delete implements JobStore.
+func (m *memoryJobStore) Delete(id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.jobs[id]
+	delete(m.jobs, id)
+	return ok, nil
+}
+
+// Get implements JobStore.
+func (m *memoryJobStore) Get(id string) (Job, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	return job, ok, nil
+}
+
+// List implements JobStore.
+func (m *memoryJobStore) List() ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// fileJobStore is a JobStore keeping the jobs in a JSON file.
+type fileJobStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileJobStore returns a JobStore which keeps the jobs in the JSON file at path,
+// rewritten atomically on each change.
+func NewFileJobStore(path string) JobStore {
+	return &fileJobStore{path: path}
+}
+
+// Save implements JobStore.
+func (f *fileJobStore) Save(job Job) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs, err := f.read()
+	if err != nil {
+		return err
+	}
+	jobs[job.ID] = job
+	return f.write(jobs)
+}
+
+// Delete implements JobStore.
+func (f *fileJobStore) Delete(id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs, err := f.read()
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := jobs[id]; !ok {
+		return false, nil
+	}
+	delete(jobs, id)
+	return true, f.write(jobs)
+}
+
+// Get implements JobStore.
+func (f *fileJobStore) Get(id string) (Job, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs, err := f.read()
+	if err != nil {
+		return Job{}, false, err
+	}
+	job, ok := jobs[id]
+	return job, ok, nil
+}
+
+// List implements JobStore.
+func (f *fileJobStore) List() ([]Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jobs, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Job, 0, len(jobs))
+	for _, j := range jobs {
+		ret = append(ret, j)
+	}
+	return ret, nil
+}
+
+func (f *fileJobStore) read() (map[string]Job, error) {
+	jobs := make(map[string]Job)
+
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return jobs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jobs, json.Unmarshal(b, &jobs)
+}
+
+func (f *fileJobStore) write(jobs map[string]Job) error {
+	b, err := json.Marshal(jobs)
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// Scheduler performs Actions at a given time or periodically, through the API it's created with,
+// hence through its rate limiters and interceptors.
+// The jobs are kept in a JobStore, so that with a persistent one they survive the restarts:
+// the jobs missed while the Scheduler wasn't running are run as soon as it starts,
+// once for the recurring ones. A job is removed or rescheduled after it's run,
+// so it may run twice if the process stops in between.
+// A failed run isn't retried: the job is passed to the function set with OnError,
+// which can schedule it again.
+type Scheduler struct {
+	store   JobStore
+	onError func(Job, error)
+	wake    chan struct{}
+	api     API
+	mu      sync.Mutex // serializes the runs and the cancellations
+}
+
+// NewScheduler returns a new Scheduler performing the jobs in store with api.
+// A nil store keeps the jobs in memory.
+func NewScheduler(api API, store JobStore) *Scheduler {
+	if store == nil {
+		store = NewMemoryJobStore()
+	}
+
+	return &Scheduler{
+		api:   api,
+		store: store,
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// OnError sets the function called when a job fails.
+func (s *Scheduler) OnError(fn func(Job, error)) *Scheduler {
+	s.onError = fn
+	return s
+}
+
+// Schedule schedules action to be performed at the given time and returns the ID of the job.
+func (s *Scheduler) Schedule(at time.Time, action Action) (string, error) {
+	return s.add(Job{Action: action, At: at})
+}
+
+// ScheduleEvery schedules action to be performed every interval starting from the given time
+// and returns the ID of the job.
+func (s *Scheduler) ScheduleEvery(start time.Time, every time.Duration, action Action) (string, error) {
+	if every <= 0 {
+		return "", errors.New("the interval of a recurring job must be positive")
+	}
+	return s.add(Job{Action: action, At: start, Every: every})
+}
+
+// Cancel removes the job with the given ID, returning ErrJobNotFound if it doesn't exist.
+// If the job is running, Cancel waits for it to finish, then it isn't run again.
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	ok, err := s.store.Delete(id)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrJobNotFound
+	}
+	s.notify()
+	return nil
+}
+
+// Jobs returns the scheduled jobs sorted by time.
+func (s *Scheduler) Jobs() ([]Job, error) {
+	jobs, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].At.Before(jobs[j].At) })
+	return jobs, nil
+}
+
+// Run performs the jobs when they're due until ctx is canceled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		jobs, err := s.Jobs()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if len(jobs) > 0 && !jobs[0].At.After(now) {
+			for _, job := range jobs {
+				if job.At.After(now) {
+					break
+				}
+				if err := s.run(job, now); err != nil {
+					return err
+				}
+			}
+			// The recurring jobs have been rescheduled, so the list is read again.
+			continue
+		}
+
+		var timer = time.NewTimer(time.Hour)
+		if len(jobs) > 0 {
+			timer.Reset(time.Until(jobs[0].At))
+		}
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// run performs job and then removes or reschedules it, unless it has been cancelled.
+func (s *Scheduler) run(job Job, now time.Time) error {
+	var res APIResponseBase
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The job may have been cancelled since it was listed.
+	if _, ok, err := s.store.Get(job.ID); err != nil || !ok {
+		return err
+	}
+
+	// The interceptors may modify the parameters, so they get a copy: their changes
+	// aren't persisted and the recurring jobs run again with the original ones.
+	params := cloneValues(job.Action.Params)
+	if err := s.api.client.get(s.api.base, job.Action.Method, params, &res); err != nil && s.onError != nil {
+		s.onError(job, err)
+	}
+
+	if job.Every <= 0 {
+		_, err := s.store.Delete(job.ID)
+		return err
+	}
+
+	// The runs missed are skipped.
+	if !job.At.After(now) {
+		job.At = job.At.Add((now.Sub(job.At)/job.Every + 1) * job.Every)
+	}
+	return s.store.Save(job)
+}
+
+func (s *Scheduler) add(job Job) (string, error) {
+	var id [16]byte
+
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	job.ID = hex.EncodeToString(id[:])
+
+	if err := s.store.Save(job); err != nil {
+		return "", err
+	}
+	s.notify()
+	return job.ID, nil
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func cloneValues(vals url.Values) url.Values {
+	ret := make(url.Values, len(vals))
+	for k, v := range vals {
+		ret[k] = append([]string(nil), v...)
+	}
+	return ret
+}
@@ -0,0 +1,187 @@
+package echosphere
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewAction(t *testing.T) {
+	action, err := NewAction(func(api API) error {
+		_, err := api.SendMessage("reminder", 1090, &MessageOptions{ParseMode: HTML})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if action.Method != "sendMessage" || action.Params.Get("chat_id") != "1090" ||
+		action.Params.Get("text") != "reminder" || action.Params.Get("parse_mode") != "HTML" {
+		t.Fatalf("unexpected action %+v", action)
+	}
+
+	action, err = NewAction(func(api API) error {
+		_, err := api.SendPhoto(NewInputFileID("photo-id"), 1090, nil)
+		return err
+	})
+	if err != nil || action.Params.Get("photo") != "photo-id" {
+		t.Fatalf("unexpected action %+v, error %v", action, err)
+	}
+
+	if _, err := NewAction(func(api API) error {
+		_, err := api.SendPhoto(NewInputFileBytes("a.jpg", []byte("a")), 1090, nil)
+		return err
+	}); err == nil {
+		t.Fatal("expected an error for an upload")
+	}
+
+	if _, err := NewAction(func(api API) error { return nil }); err == nil {
+		t.Fatal("expected an error when no call is made")
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	disableChatLimit(t)
+
+	var (
+		mu    sync.Mutex
+		texts = make(chan string, 100)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/deleteMessage") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: message to delete not found"}`))
+			return
+		}
+		texts <- r.URL.Query().Get("text")
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	var (
+		api    = NewLocalAPI(srv.URL, "123:token")
+		store  = NewFileJobStore(filepath.Join(t.TempDir(), "jobs.json"))
+		failed = make(chan error, 10)
+		s      = NewScheduler(api, store).OnError(func(_ Job, err error) { failed <- err })
+	)
+
+	action := func(text string) Action {
+		a, err := NewAction(func(api API) error {
+			_, err := api.SendMessage(text, 1091, nil)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	now := time.Now()
+	if _, err := s.Schedule(now.Add(50*time.Millisecond), action("once")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ScheduleEvery(now.Add(20*time.Millisecond), 40*time.Millisecond, action("every")); err != nil {
+		t.Fatal(err)
+	}
+	canceled, err := s.Schedule(now.Add(30*time.Millisecond), action("canceled"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(canceled); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(canceled); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound, got %v", err)
+	}
+
+	del, _ := NewAction(func(api API) error {
+		_, err := api.DeleteMessage(1091, 1)
+		return err
+	})
+	if _, err := s.Schedule(now, del); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	close(texts)
+	counts := make(map[string]int)
+	for text := range texts {
+		counts[text]++
+	}
+	if counts["once"] != 1 || counts["every"] < 3 || counts["canceled"] != 0 {
+		t.Fatalf("unexpected messages %v", counts)
+	}
+	if err := <-failed; !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected the failure of deleteMessage, got %v", err)
+	}
+
+	// Only the recurring job is left, and survives in the store.
+	jobs, err := NewScheduler(api, store).Jobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].Every != 40*time.Millisecond || !jobs[0].At.After(now) {
+		t.Fatalf("unexpected jobs %+v", jobs)
+	}
+}
+
+func TestSchedulerCancelRunning(t *testing.T) {
+	disableChatLimit(t)
+
+	var (
+		s        *Scheduler
+		id       string
+		requests = make(chan struct{}, 10)
+		canceled = make(chan error, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(requests) == 0 {
+			// The job is cancelled while it's running.
+			go func() { canceled <- s.Cancel(id) }()
+		}
+		requests <- struct{}{}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer srv.Close()
+
+	s = NewScheduler(NewLocalAPI(srv.URL, "123:token"), nil)
+	action, _ := NewAction(func(api API) error {
+		_, err := api.SendMessage("every", 1092, nil)
+		return err
+	})
+
+	var err error
+	// The job is rescheduled far enough not to run again, whatever the time Cancel takes to get the lock.
+	if id, err = s.ScheduleEvery(time.Now(), time.Hour, action); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	if err := <-canceled; err != nil {
+		t.Fatal(err)
+	}
+	if n := len(requests); n != 1 {
+		t.Fatalf("expected the job to run once, got %d runs", n)
+	}
+	// The job must not be saved again by the run it was cancelled during.
+	if jobs, _ := s.Jobs(); len(jobs) != 0 {
+		t.Fatalf("expected no jobs, got %+v", jobs)
+	}
+}
@@ -3,7 +3,6 @@ package echosphere
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"time"
@@ -29,7 +28,7 @@ func PollingUpdatesOptions(token string, dropPendingUpdates bool, opts UpdateOpt
 
 		// deletes webhook if present to run in long polling mode
 		if _, err := api.DeleteWebhook(dropPendingUpdates); err != nil {
-			log.Println("echosphere.PollingUpdates", err)
+			api.client.log().Error("can't delete the webhook", "error", redact(err.Error()))
 		}
 
 		for {
@@ -39,7 +38,7 @@ func PollingUpdatesOptions(token string, dropPendingUpdates bool, opts UpdateOpt
 
 			response, err := api.GetUpdates(&opts)
 			if err != nil {
-				log.Println("echosphere.PollingUpdates", err)
+				api.client.log().Error("can't get the updates", "error", redact(err.Error()))
 				time.Sleep(5 * time.Second)
 				continue
 			}
@@ -92,12 +91,12 @@ func WebhookUpdatesOptions(whURL, token string, dropPendingUpdates bool, opts *W
 
 		jsn, err := readRequest(r)
 		if err != nil {
-			log.Println("echosphere.WebhookUpdates", err)
+			api.client.log().Error("can't read the webhook request", "error", redact(err.Error()))
 			return
 		}
 
 		if err := json.Unmarshal(jsn, &update); err != nil {
-			log.Println("echosphere.WebhookUpdates", err)
+			api.client.log().Error("can't decode the update", "error", err)
 			return
 		}
 
@@ -109,7 +108,7 @@ func WebhookUpdatesOptions(whURL, token string, dropPendingUpdates bool, opts *W
 		port := fmt.Sprintf(":%s", u.Port())
 		for {
 			if err := http.ListenAndServe(port, nil); err != nil {
-				log.Println("echosphere.WebhookUpdates", err)
+				api.client.log().Error("webhook server stopped", "error", err)
 				time.Sleep(5 * time.Second)
 			}
 		}
@@ -0,0 +1,259 @@
+/*
+ * Echosphere
+ * Copyright (C) 2018-2022 The Echosphere Devs
+ *
+ * Echosphere is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * Echosphere is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package echosphere
+
+import (
+	"errors"
+	"unicode/utf16"
+)
+
+// These are the maximum lengths, in UTF-16 code units, accepted by Telegram
+// for the text of a message and for the caption of a media.
+const (
+	MaxMessageLength = 4096
+	MaxCaptionLength = 1024
+)
+
+// TextChunk is a portion of a longer text along with the entities that apply to it.
+type TextChunk struct {
+	Text     string
+	Entities []MessageEntity
+}
+
+// CaptionFunc sends a media message with the given caption, caption entities and reply markup.
+// It's used by SendLongCaption to send the media along with the first part of the caption.
+type CaptionFunc func(caption string, entities []MessageEntity, markup ReplyMarkup) (APIResponseMessage, error)
+
+// SplitText splits text into chunks of at most limit UTF-16 code units,
+// preferably at paragraph, line or word boundaries.
+// A limit lower than 2 is raised to 2, the length of the longest code point.
+// The entities are split accordingly and their offsets are re-based on each chunk.
+func SplitText(text string, entities []MessageEntity, limit int) []TextChunk {
+	return splitText(text, entities, limit, limit)
+}
+
+// SendLongMessage is like SendMessage but splits text longer than MaxMessageLength
+// into several messages, which are sent in order.
+// If a parse mode is specified in opts, the text is parsed locally so that the formatting
+// is preserved across the messages, thus only HTML and MarkdownV2 are supported.
+// The reply markup is attached only to the last message and the reply parameters only to the first one.
+// All the messages sent are returned, even when an error interrupts the sequence.
+func (a API) SendLongMessage(text string, chatID int64, opts *MessageOptions) (res []APIResponseMessage, err error) {
+	text, entities, err := parseText(text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := SplitText(text, entities, MaxMessageLength)
+	if len(chunks) == 0 {
+		chunks = []TextChunk{{Text: text, Entities: entities}}
+	}
+	return a.sendChunks(chatID, chunks, opts, true)
+}
+
+// SendLongCaption sends a media through send with the first MaxCaptionLength UTF-16 code units
+// of caption, sending the overflow, if any, as follow-up messages.
+// The parse mode, entities and other options of the follow-up messages are taken from opts,
+// whose reply markup is passed to send when the caption fits, otherwise it's attached to the last message.
+// The reply parameters in opts are ignored, since the media is expected to carry its own.
+func (a API) SendLongCaption(chatID int64, caption string, send CaptionFunc, opts *MessageOptions) (res []APIResponseMessage, err error) {
+	var markup ReplyMarkup
+
+	caption, entities, err := parseText(caption, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts != nil {
+		markup = opts.ReplyMarkup
+	}
+
+	chunks := splitText(caption, entities, MaxCaptionLength, MaxMessageLength)
+	if len(chunks) <= 1 {
+		r, err := send(caption, entities, markup)
+		return []APIResponseMessage{r}, err
+	}
+
+	r, err := send(chunks[0].Text, chunks[0].Entities, nil)
+	res = append(res, r)
+	if err != nil {
+		return res, err
+	}
+
+	follow, err := a.sendChunks(chatID, chunks[1:], opts, false)
+	return append(res, follow...), err
+}
+
+// sendChunks sends each chunk as a separate message with the given options,
+// keeping the reply markup only on the last one and the reply parameters only
+// on the first one, if withReply is true.
+func (a API) sendChunks(chatID int64, chunks []TextChunk, opts *MessageOptions, withReply bool) (res []APIResponseMessage, err error) {
+	for i, c := range chunks {
+		var o MessageOptions
+
+		if opts != nil {
+			o = *opts
+		}
+
+		o.ParseMode = ""
+		o.Entities = c.Entities
+		if i > 0 || !withReply {
+			o.ReplyParameters = ReplyParameters{}
+		}
+		if i < len(chunks)-1 {
+			o.ReplyMarkup = nil
+		}
+
+		r, err := a.SendMessage(c.Text, chatID, &o)
+		res = append(res, r)
+		if err != nil {
+			return res, err
+		}
+	}
+	return
+}
+
+// parseText returns the plain text and the entities described by the parse mode
+// or the entities in opts.
+func parseText(text string, opts *MessageOptions) (string, []MessageEntity, error) {
+	if opts == nil {
+		return text, nil, nil
+	}
+
+	switch opts.ParseMode {
+	case HTML:
+		return ParseHTML(text)
+	case MarkdownV2:
+		return ParseMarkdownV2(text)
+	case "":
+		return text, opts.Entities, nil
+	default:
+		return "", nil, errors.New("the legacy Markdown parse mode can't be split, use MarkdownV2 or HTML instead")
+	}
+}
+
+// splitText works like SplitText but allows a different limit for the first chunk.
+func splitText(text string, entities []MessageEntity, first, limit int) (chunks []TextChunk) {
+	// A chunk must fit at least a surrogate pair, so that the text always advances.
+	if first < 2 {
+		first = 2
+	}
+	if limit < 2 {
+		limit = 2
+	}
+
+	u := utf16.Encode([]rune(text))
+	if len(u) <= first {
+		return []TextChunk{{Text: text, Entities: entities}}
+	}
+
+	entities = normalizeEntities(entities, len(u))
+	for start, l := 0, first; start < len(u); l = limit {
+		end := splitPoint(u, start, l)
+
+		// Telegram trims the whitespace around messages, so it's dropped here
+		// rather than being counted towards the limit of the next chunk.
+		trim := end
+		for trim > start && isSpace(u[trim-1]) {
+			trim--
+		}
+		if trim > start {
+			chunks = append(chunks, TextChunk{
+				Text:     utf16String(u[start:trim]),
+				Entities: sliceEntities(entities, start, trim),
+			})
+		}
+
+		for end < len(u) && isSpace(u[end]) {
+			end++
+		}
+		start = end
+	}
+
+	return
+}
+
+// splitPoint returns the index at which the text starting at u[start] should be split
+// so that the chunk is at most l code units long.
+func splitPoint(u []uint16, start, l int) int {
+	if len(u)-start <= l {
+		return len(u)
+	}
+
+	var (
+		window    = u[start : start+l]
+		line      = -1
+		word      = -1
+		paragraph = -1
+	)
+
+	for i := len(window) - 1; i > 0; i-- {
+		switch window[i] {
+		case '\n':
+			if paragraph < 0 && window[i-1] == '\n' {
+				paragraph = i + 1
+			}
+			if line < 0 {
+				line = i + 1
+			}
+		case ' ', '\t':
+			if word < 0 {
+				word = i + 1
+			}
+		}
+	}
+
+	switch {
+	case paragraph > l/2:
+		return start + paragraph
+	case line > l/2:
+		return start + line
+	case word > 0:
+		return start + word
+	case utf16.IsSurrogate(rune(window[l-1])) && window[l-1] < 0xdc00:
+		// Never split a surrogate pair.
+		return start + l - 1
+	default:
+		return start + l
+	}
+}
+
+func isSpace(c uint16) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+// sliceEntities returns the entities which intersect [start, end) re-based on start.
+func sliceEntities(entities []MessageEntity, start, end int) (ret []MessageEntity) {
+	for _, e := range entities {
+		s, f := e.Offset, e.Offset+e.Length
+		if s < start {
+			s = start
+		}
+		if f > end {
+			f = end
+		}
+		if s >= f {
+			continue
+		}
+
+		e.Offset, e.Length = s-start, f-s
+		ret = append(ret, e)
+	}
+	return
+}
@@ -0,0 +1,168 @@
+package echosphere
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	text := "first paragraph\n\nsecond one is longer"
+	entities := []MessageEntity{
+		{Type: BoldEntity, Offset: 6, Length: 20},
+	}
+
+	chunks := SplitText(text, entities, 25)
+	expected := []TextChunk{
+		{
+			Text:     "first paragraph",
+			Entities: []MessageEntity{{Type: BoldEntity, Offset: 6, Length: 9}},
+		},
+		{
+			Text:     "second one is longer",
+			Entities: []MessageEntity{{Type: BoldEntity, Offset: 0, Length: 9}},
+		},
+	}
+
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, chunks)
+	}
+}
+
+func TestSplitTextLimit(t *testing.T) {
+	text := strings.Repeat("word ", 2000) + strings.Repeat("😀", 3000)
+
+	for i, c := range SplitText(text, nil, MaxMessageLength) {
+		if l := UTF16Len(c.Text); l > MaxMessageLength {
+			t.Fatalf("chunk #%d exceeds the limit: %d", i, l)
+		}
+		if strings.ContainsRune(c.Text, '�') {
+			t.Fatalf("chunk #%d contains a split surrogate pair", i)
+		}
+	}
+}
+
+func TestSplitTextShort(t *testing.T) {
+	chunks := SplitText("short", nil, MaxMessageLength)
+
+	if len(chunks) != 1 || chunks[0].Text != "short" {
+		t.Fatalf("unexpected chunks %+v", chunks)
+	}
+}
+
+func TestSplitTextTinyLimit(t *testing.T) {
+	for limit, tc := range map[int]struct {
+		text     string
+		expected []string
+	}{
+		0: {"abc", []string{"ab", "c"}},
+		1: {"😀😀😀", []string{"😀", "😀", "😀"}},
+		2: {"a😀b", []string{"a", "😀", "b"}},
+	} {
+		var texts []string
+		for _, c := range SplitText(tc.text, nil, limit) {
+			texts = append(texts, c.Text)
+		}
+		if !reflect.DeepEqual(texts, tc.expected) {
+			t.Fatalf("limit %d: expected %q, got %q", limit, tc.expected, texts)
+		}
+	}
+}
+
+// messageServer records the parameters of the messages sent to it.
+func messageServer(t *testing.T, sent *[]url.Values) *httptest.Server {
+	var mu sync.Mutex
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*sent = append(*sent, r.URL.Query())
+		n := len(*sent)
+		mu.Unlock()
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSendLongMessage(t *testing.T) {
+	var sent []url.Values
+
+	disableChatLimit(t)
+
+	api := NewLocalAPI(messageServer(t, &sent).URL, "123:token")
+	text := "<b>" + strings.Repeat("a", MaxMessageLength) + "</b> end"
+
+	res, err := api.SendLongMessage(text, 1120, &MessageOptions{
+		ParseMode:       HTML,
+		ReplyMarkup:     InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{NewInlineButtonCallback("ok", "ok")}}},
+		ReplyParameters: ReplyParameters{MessageID: 7},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 2 || len(sent) != 2 || res[1].Result.ID != 2 {
+		t.Fatalf("expected 2 messages, got %d responses and %d requests", len(res), len(sent))
+	}
+	if sent[0].Get("text") != strings.Repeat("a", MaxMessageLength) || sent[1].Get("text") != "end" {
+		t.Fatalf("unexpected texts %q and %q", sent[0].Get("text"), sent[1].Get("text"))
+	}
+	if sent[0].Get("parse_mode") != "" || !strings.Contains(sent[0].Get("entities"), `"bold"`) {
+		t.Fatalf("expected the entities to be sent instead of the parse mode, got %v", sent[0])
+	}
+	if sent[0].Get("reply_parameters") == "" || sent[1].Get("reply_parameters") != "" {
+		t.Fatalf("expected the reply parameters only on the first message, got %q and %q",
+			sent[0].Get("reply_parameters"), sent[1].Get("reply_parameters"))
+	}
+	if sent[0].Get("reply_markup") != "" || sent[1].Get("reply_markup") == "" {
+		t.Fatalf("expected the reply markup only on the last message, got %q and %q",
+			sent[0].Get("reply_markup"), sent[1].Get("reply_markup"))
+	}
+}
+
+func TestSendLongCaption(t *testing.T) {
+	var (
+		sent     []url.Values
+		captions []string
+		markups  []ReplyMarkup
+	)
+
+	disableChatLimit(t)
+
+	api := NewLocalAPI(messageServer(t, &sent).URL, "123:token")
+	send := func(caption string, _ []MessageEntity, markup ReplyMarkup) (APIResponseMessage, error) {
+		captions = append(captions, caption)
+		markups = append(markups, markup)
+		return APIResponseMessage{Result: &Message{ID: 100}}, nil
+	}
+	kbd := InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{NewInlineButtonCallback("ok", "ok")}}}
+
+	// The caption fits, so the media carries the reply markup.
+	res, err := api.SendLongCaption(1121, "short", send, &MessageOptions{ReplyMarkup: kbd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || len(sent) != 0 || captions[0] != "short" || markups[0] == nil {
+		t.Fatalf("unexpected result %+v with captions %q", res, captions)
+	}
+
+	caption := strings.Repeat("a", MaxCaptionLength) + " " + strings.Repeat("b", 10)
+	if res, err = api.SendLongCaption(1121, caption, send, &MessageOptions{ReplyMarkup: kbd}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 2 || res[0].Result.ID != 100 || len(sent) != 1 {
+		t.Fatalf("expected the media and a follow-up message, got %d responses and %d requests", len(res), len(sent))
+	}
+	if captions[1] != strings.Repeat("a", MaxCaptionLength) || markups[1] != nil {
+		t.Fatalf("unexpected caption %q with markup %v", captions[1], markups[1])
+	}
+	if sent[0].Get("text") != strings.Repeat("b", 10) || sent[0].Get("reply_markup") == "" {
+		t.Fatalf("expected the overflow with the reply markup, got %v", sent[0])
+	}
+}
@@ -143,7 +143,13 @@ func (a API) UploadStickerFile(userID int64, sticker InputFile, format StickerFo
 
 	vals.Set("user_id", itoa(userID))
 	vals.Set("sticker_format", string(format))
-	return res, a.client.postFile(a.base, "uploadStickerFile", "sticker", sticker, InputFile{}, vals, &res)
+	return res, a.sendFiles([]string{"sticker"}, []InputFile{sticker}, nil, func(files []InputFile) ([]string, error) {
+		res = APIResponseFile{}
+		if err := a.client.postFile(a.base, "uploadStickerFile", "sticker", files[0], InputFile{}, vals, &res); err != nil || res.Result == nil {
+			return nil, err
+		}
+		return []string{res.Result.FileID}, nil
+	})
 }
 
 // CreateNewStickerSet is used to create a new sticker set owned by a user.
@@ -153,7 +159,10 @@ func (a API) CreateNewStickerSet(userID int64, name, title string, stickers []In
 	vals.Set("user_id", itoa(userID))
 	vals.Set("name", name)
 	vals.Set("title", title)
-	return res, a.client.postStickers(a.base, "createNewStickerSet", addValues(vals, opts), &res, stickers...)
+	return res, a.sendStickers(userID, stickers, func(stickers []InputSticker) error {
+		res = APIResponseBool{}
+		return a.client.postStickers(a.base, "createNewStickerSet", addValues(vals, opts), &res, stickers...)
+	})
 }
 
 // AddStickerToSet is used to add a new sticker to a set created by the bot.
@@ -162,7 +171,10 @@ func (a API) AddStickerToSet(userID int64, name string, sticker InputSticker) (r
 
 	vals.Set("user_id", itoa(userID))
 	vals.Set("name", name)
-	return res, a.client.postStickers(a.base, "addStickerToSet", vals, &res, sticker)
+	return res, a.sendStickers(userID, []InputSticker{sticker}, func(stickers []InputSticker) error {
+		res = APIResponseBool{}
+		return a.client.postStickers(a.base, "addStickerToSet", vals, &res, stickers...)
+	})
 }
 
 // SetStickerPositionInSet is used to move a sticker in a set created by the bot to a specific position.
@@ -190,7 +202,10 @@ func (a API) ReplaceStickerInSet(userID int64, name string, old_sticker string,
 	vals.Set("user_id", itoa(userID))
 	vals.Set("name", name)
 	vals.Set("old_sticker", old_sticker)
-	return res, a.client.postStickers(a.base, "replaceStickerInSet", vals, &res, sticker)
+	return res, a.sendStickers(userID, []InputSticker{sticker}, func(stickers []InputSticker) error {
+		res = APIResponseBool{}
+		return a.client.postStickers(a.base, "replaceStickerInSet", vals, &res, stickers...)
+	})
 }
 
 // SetStickerEmojiList is used to change the list of emoji assigned to a regular or custom emoji sticker.
@@ -245,7 +260,7 @@ func (a API) SetStickerSetThumbnail(name string, userID int64, thumbnail InputFi
 	vals.Set("name", name)
 	vals.Set("user_id", itoa(userID))
 	vals.Set("format", string(format))
-	return res, a.client.postFile(a.base, "setStickerSetThumbnail", "thumbnail", thumbnail, InputFile{}, vals, &res)
+	return res, a.client.postFile(a.base, "setStickerSetThumbnail", "thumbnail", a.localFile(thumbnail), InputFile{}, vals, &res)
 }
 
 // SetCustomEmojiStickerSetThumbnail is used to set the thumbnail of a custom emoji sticker set.
@@ -46,6 +46,7 @@ type Update struct {
 	MyChatMember            *ChatMemberUpdated           `json:"my_chat_member,omitempty"`
 	ChatMember              *ChatMemberUpdated           `json:"chat_member,omitempty"`
 	ID                      int                          `json:"update_id"`
+	group                   *MediaGroup
 }
 
 // ChatID returns the ID of the chat the update is coming from.
@@ -120,9 +121,10 @@ type APIResponse interface {
 // APIResponseBase is a base type that represents the incoming response from Telegram servers.
 // Used by APIResponse* to slim down the implementation.
 type APIResponseBase struct {
-	Description string `json:"description,omitempty"`
-	ErrorCode   int    `json:"error_code,omitempty"`
-	Ok          bool   `json:"ok"`
+	Parameters  *ResponseParameters `json:"parameters,omitempty"`
+	Description string              `json:"description,omitempty"`
+	ErrorCode   int                 `json:"error_code,omitempty"`
+	Ok          bool                `json:"ok"`
 }
 
 // Base returns the APIResponseBase itself.
@@ -584,6 +586,7 @@ type Message struct {
 	Document                      *Document                      `json:"document,omitempty"`
 	PinnedMessage                 *Message                       `json:"pinned_message,omitempty"`
 	LeftChatMember                *User                          `json:"left_chat_member,omitempty"`
+	PassportData                  *PassportData                  `json:"passport_data,omitempty"`
 	Animation                     *Animation                     `json:"animation,omitempty"`
 	Audio                         *Audio                         `json:"audio,omitempty"`
 	Voice                         *Voice                         `json:"voice,omitempty"`
@@ -1045,6 +1048,7 @@ const (
 type InputMedia interface {
 	media() InputFile
 	thumbnail() InputFile
+	withFiles(media, thumbnail InputFile) InputMedia
 }
 
 // GroupableInputMedia is an interface for the various groupable media types.
@@ -1139,6 +1143,12 @@ func (i InputMediaPhoto) media() InputFile { return i.Media }
 // thumbnail is a method which allows to obtain the Thumbnail (type InputFile) field from the InputMedia* struct.
 func (i InputMediaPhoto) thumbnail() InputFile { return InputFile{} }
 
+// withFiles is a method which returns a copy of the InputMedia* struct with the given Media field.
+func (i InputMediaPhoto) withFiles(media, _ InputFile) InputMedia {
+	i.Media = media
+	return i
+}
+
 // groupable is a dummy method which exists to implement the interface GroupableInputMedia.
 func (i InputMediaPhoto) groupable() {}
 
@@ -1164,6 +1174,12 @@ func (i InputMediaVideo) media() InputFile { return i.Media }
 // thumbnail is a method which allows to obtain the Thumbnail (type InputFile) field from the InputMedia* struct.
 func (i InputMediaVideo) thumbnail() InputFile { return i.Thumbnail }
 
+// withFiles is a method which returns a copy of the InputMedia* struct with the given Media and Thumbnail fields.
+func (i InputMediaVideo) withFiles(media, thumbnail InputFile) InputMedia {
+	i.Media, i.Thumbnail = media, thumbnail
+	return i
+}
+
 // groupable is a dummy method which exists to implement the interface GroupableInputMedia.
 func (i InputMediaVideo) groupable() {}
 
@@ -1188,6 +1204,12 @@ func (i InputMediaAnimation) media() InputFile { return i.Media }
 // thumbnail is a method which allows to obtain the Thumbnail (type InputFile) field from the InputMedia* struct.
 func (i InputMediaAnimation) thumbnail() InputFile { return i.Thumbnail }
 
+// withFiles is a method which returns a copy of the InputMedia* struct with the given Media and Thumbnail fields.
+func (i InputMediaAnimation) withFiles(media, thumbnail InputFile) InputMedia {
+	i.Media, i.Thumbnail = media, thumbnail
+	return i
+}
+
 // InputMediaAudio represents an audio file to be treated as music to be sent.
 // Type MUST BE "audio".
 type InputMediaAudio struct {
@@ -1208,6 +1230,12 @@ func (i InputMediaAudio) media() InputFile { return i.Media }
 // thumbnail is a method which allows to obtain the Thumbnail (type InputFile) field from the InputMedia* struct.
 func (i InputMediaAudio) thumbnail() InputFile { return i.Thumbnail }
 
+// withFiles is a method which returns a copy of the InputMedia* struct with the given Media and Thumbnail fields.
+func (i InputMediaAudio) withFiles(media, thumbnail InputFile) InputMedia {
+	i.Media, i.Thumbnail = media, thumbnail
+	return i
+}
+
 // groupable is a dummy method which exists to implement the interface GroupableInputMedia.
 func (i InputMediaAudio) groupable() {}
 
@@ -1229,6 +1257,12 @@ func (i InputMediaDocument) media() InputFile { return i.Media }
 // thumbnail is a method which allows to obtain the Thumbnail (type InputFile) field from the InputMedia* struct.
 func (i InputMediaDocument) thumbnail() InputFile { return i.Thumbnail }
 
+// withFiles is a method which returns a copy of the InputMedia* struct with the given Media and Thumbnail fields.
+func (i InputMediaDocument) withFiles(media, thumbnail InputFile) InputMedia {
+	i.Media, i.Thumbnail = media, thumbnail
+	return i
+}
+
 // groupable is a dummy method which exists to implement the interface GroupableInputMedia.
 func (i InputMediaDocument) groupable() {}
 